@@ -1,17 +1,22 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/diamondburned/arikawa/v3/api"
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/sendpart"
 	"github.com/lilacse/kagura/database"
 	"github.com/lilacse/kagura/dataservices/songdata"
 	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/imagebuilder"
 	"github.com/lilacse/kagura/store"
 )
 
@@ -30,61 +35,115 @@ func NewB30Handler(store *store.Store, db *database.Service, songdata *songdata.
 }
 
 func (h *b30Handler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
-	var data *discord.CommandInteraction
+	data := e.Data.(*discord.CommandInteraction)
 
-	switch e.Data.(type) {
-	case *discord.CommandInteraction:
-		data = e.Data.(*discord.CommandInteraction)
-	default:
-		return false
-	}
+	st := h.store.Bot.State()
 
-	if data.Name != "b30" {
-		return false
-	}
+	sess := database.GetSession(ctx)
 
-	st := h.store.Bot.State()
+	viewerId := int64(e.Sender().ID)
 
-	sess, err := h.db.NewSession(ctx)
+	loc, err := getUserLocation(ctx, viewerId)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
 	}
 
-	defer func() {
-		err := sess.Conn.Close()
-		if err != nil {
-			logAndSendCommandError(ctx, st, err, e)
+	// the snapshot includes the whole given day in the timezone of the viewer, so it ends at the start of the next day.
+	before := int64(math.MaxInt64)
+	asOfStr := data.Options.Find("as_of").String()
+	if asOfStr != "" {
+		t, errStr, ok := parseDate(asOfStr, loc)
+		if !ok {
+			sendCommandErrorReply(st, errStr, e)
+			return true
 		}
-	}()
+		before = t.AddDate(0, 0, 1).UnixMilli()
+	}
 
 	scoresRepo := sess.GetScoresRepo()
 
-	count, err := scoresRepo.GetUserPlayedChartCount(ctx, int64(e.Sender().ID))
+	owner := getTargetUser(data, e)
+	ownerId := int64(owner.ID)
+
+	allowed, err := canViewScores(ctx, st, sess.GetUserSettingsRepo(), ownerId, viewerId, e.GuildID)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	if !allowed {
+		sendCommandErrorReply(st, fmt.Sprintf("The scores of %s are not visible to you!", owner.Mention()), e)
+		return true
+	}
+
+	count, err := scoresRepo.GetUserPlayedChartCountBefore(ctx, ownerId, before)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
 	}
 
 	if count == 0 {
-		sendCommandErrorReply(st, "You don't have any scores saved!", e)
+		switch {
+		case before != math.MaxInt64:
+			sendCommandErrorReply(st, fmt.Sprintf("No scores were saved on or before %s!", asOfStr), e)
+		case ownerId == viewerId:
+			sendCommandErrorReply(st, "You don't have any scores saved!", e)
+		default:
+			sendCommandErrorReply(st, fmt.Sprintf("%s doesn't have any scores saved!", owner.Mention()), e)
+		}
 		return true
 	}
 
-	avgRt, avgScore, err := scoresRepo.GetBestScoreRatingsAverage(ctx, int64(e.Sender().ID), 30)
+	avgRt, avgScore, err := scoresRepo.GetBestScoreRatingsAverageBefore(ctx, ownerId, before, 30)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
 	}
 
-	entries, err := scoresRepo.GetBestScoresByUserWithOffset(ctx, int64(e.Sender().ID), 0, 5)
+	image, _ := data.Options.Find("image").BoolValue()
+	if image {
+		entries, err := scoresRepo.GetBestScoresByUserWithOffsetBefore(ctx, ownerId, before, 0, 30)
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		username := owner.DisplayOrUsername()
+		if before != math.MaxInt64 {
+			username = fmt.Sprintf("%s (as of %s)", username, getAsOfDate(before, loc))
+		}
+
+		img, err := createB30Card(h, username, avgRt, avgScore, entries)
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		embed := discord.Embed{
+			Title: "Best-30 Card" + getAsOfTitleSuffix(before, loc),
+			Image: &discord.EmbedImage{
+				URL: "attachment://b30.png",
+			},
+		}
+		embed = describeScoresOwner(embed, ownerId, viewerId)
+
+		files := []sendpart.File{
+			{Name: "b30.png", Reader: bytes.NewReader(img)},
+		}
+
+		sendInteractionResponseWithFiles(st, embedbuilder.Info(embed), []discord.TopLevelComponent{}, files, e)
+		return true
+	}
+
+	entries, err := scoresRepo.GetBestScoresByUserWithOffsetBefore(ctx, ownerId, before, 0, 5)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
 	}
 
-	embed := createB30Embed(h, avgRt, avgScore, entries, 0)
-	components := createB30PageButtons(int64(e.Sender().ID), count, 0)
+	embed := describeScoresOwner(createB30Embed(h, avgRt, avgScore, entries, 0, before, loc), ownerId, viewerId)
+	components := createB30PageButtons(viewerId, ownerId, count, 0, before)
 
 	sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
 
@@ -97,51 +156,67 @@ func (h *b30Handler) HandleB30PageSelect(ctx context.Context, e *gateway.Interac
 	val := e.Data.(*discord.ButtonInteraction).CustomID
 
 	params := strings.Split(string(val), ",")
-	receiver := params[1]
-	if receiver != "b30" {
-		return false
-	}
 
-	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	viewerId, _ := strconv.ParseInt(params[0], 10, 64)
+
+	// buttons sent before other users' b30 could be viewed do not have the owner, which is always the viewer.
+	ownerId := viewerId
 	offset, _ := strconv.Atoi(params[2])
+	if len(params) > 3 {
+		ownerId, _ = strconv.ParseInt(params[2], 10, 64)
+		offset, _ = strconv.Atoi(params[3])
+	}
+
+	// buttons sent before snapshots were supported do not have the time bound.
+	before := int64(math.MaxInt64)
+	if len(params) > 4 {
+		before, _ = strconv.ParseInt(params[4], 10, 64)
+	}
 
 	pageIdx := offset / 5
 
-	sess, err := h.db.NewSession(ctx)
+	sess := database.GetSession(ctx)
+
+	loc, err := getUserLocation(ctx, viewerId)
 	if err != nil {
 		logAndSendInteractionError(ctx, st, err, e)
 		return true
 	}
 
-	defer func() {
-		err := sess.Conn.Close()
-		if err != nil {
-			logAndSendInteractionError(ctx, st, err, e)
-		}
-	}()
-
 	scoresRepo := sess.GetScoresRepo()
 
-	count, err := scoresRepo.GetUserPlayedChartCount(ctx, userId)
+	// the owner might have changed their privacy setting since the message was sent.
+	allowed, err := canViewScores(ctx, st, sess.GetUserSettingsRepo(), ownerId, viewerId, e.GuildID)
 	if err != nil {
 		logAndSendInteractionError(ctx, st, err, e)
 		return true
 	}
 
-	avgRt, avgScore, err := scoresRepo.GetBestScoreRatingsAverage(ctx, userId, 30)
+	if !allowed {
+		sendInteractionReply(st, embedbuilder.UserError(fmt.Sprintf("The scores of <@%v> are no longer visible to you!", ownerId)), e)
+		return true
+	}
+
+	count, err := scoresRepo.GetUserPlayedChartCountBefore(ctx, ownerId, before)
 	if err != nil {
 		logAndSendInteractionError(ctx, st, err, e)
 		return true
 	}
 
-	entries, err := scoresRepo.GetBestScoresByUserWithOffset(ctx, userId, offset, 5)
+	avgRt, avgScore, err := scoresRepo.GetBestScoreRatingsAverageBefore(ctx, ownerId, before, 30)
 	if err != nil {
 		logAndSendInteractionError(ctx, st, err, e)
 		return true
 	}
 
-	embed := createB30Embed(h, avgRt, avgScore, entries, offset)
-	components := createB30PageButtons(userId, count, pageIdx)
+	entries, err := scoresRepo.GetBestScoresByUserWithOffsetBefore(ctx, ownerId, before, offset, 5)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	embed := describeScoresOwner(createB30Embed(h, avgRt, avgScore, entries, offset, before, loc), ownerId, viewerId)
+	components := createB30PageButtons(viewerId, ownerId, count, pageIdx, before)
 
 	resp := api.InteractionResponse{
 		Type: api.UpdateMessage,
@@ -156,7 +231,7 @@ func (h *b30Handler) HandleB30PageSelect(ctx context.Context, e *gateway.Interac
 	return true
 }
 
-func createB30Embed(h *b30Handler, avgRt float64, avgScore float64, entries []database.ScoreRecordRating, idx int) discord.Embed {
+func createB30Embed(h *b30Handler, avgRt float64, avgScore float64, entries []database.ScoreRecordRating, idx int, before int64, loc *time.Location) discord.Embed {
 	entriesBuilder := strings.Builder{}
 
 	for i, s := range entries {
@@ -175,7 +250,7 @@ func createB30Embed(h *b30Handler, avgRt float64, avgScore float64, entries []da
 	}
 
 	embed := discord.Embed{
-		Title: "Highest Play Ratings from Saved Scores",
+		Title: "Highest Play Ratings from Saved Scores" + getAsOfTitleSuffix(before, loc),
 		Fields: []discord.EmbedField{
 			{
 				Name:  "Best-30 Stats",
@@ -188,22 +263,72 @@ func createB30Embed(h *b30Handler, avgRt float64, avgScore float64, entries []da
 		},
 	}
 
+	if before != math.MaxInt64 {
+		embed.Footer = &discord.EmbedFooter{
+			Text: fmt.Sprintf("Snapshot of the scores saved until the end of %s (%s).", getAsOfDate(before, loc), loc.String()),
+		}
+	}
+
 	return embed
 }
 
-func createB30PageButtons(userId int64, count int, pageIdx int) []discord.TopLevelComponent {
+// Returns the last day included in a snapshot ending at before, in the given location.
+func getAsOfDate(before int64, loc *time.Location) string {
+	return time.UnixMilli(before - 1).In(loc).Format("2006-01-02")
+}
+
+func getAsOfTitleSuffix(before int64, loc *time.Location) string {
+	if before == math.MaxInt64 {
+		return ""
+	}
+
+	return fmt.Sprintf(" as of %s", getAsOfDate(before, loc))
+}
+
+func createB30Card(h *b30Handler, username string, avgRt float64, avgScore float64, entries []database.ScoreRecordRating) ([]byte, error) {
+	cardEntries := make([]imagebuilder.B30CardEntry, 0, len(entries))
+
+	for _, s := range entries {
+		chart, song, _ := h.songdata.GetChartById(s.ChartId)
+
+		badge := getScoreGrade(s.Score)
+		if s.Score >= 10000000 {
+			badge = "PM"
+		}
+
+		cardEntries = append(cardEntries, imagebuilder.B30CardEntry{
+			Title:   song.AltTitle,
+			DiffKey: chart.Diff,
+			Level:   chart.Level,
+			CC:      chart.GetCCString(),
+			Score:   s.Score,
+			Rating:  s.Rating,
+			Badge:   badge,
+		})
+	}
+
+	return imagebuilder.RenderB30Card(imagebuilder.B30Card{
+		Username:  username,
+		AvgRating: avgRt,
+		AvgScore:  avgScore,
+		Entries:   cardEntries,
+	})
+}
+
+// The buttons are only usable by the viewer, while the entries shown are always of the owner.
+func createB30PageButtons(viewerId int64, ownerId int64, count int, pageIdx int, before int64) []discord.TopLevelComponent {
 	prevOffset := (pageIdx - 1) * 5
 	nextOffset := (pageIdx + 1) * 5
 
 	return []discord.TopLevelComponent{
 		&discord.ActionRowComponent{
 			&discord.ButtonComponent{
-				CustomID: discord.ComponentID(fmt.Sprintf("%v,b30,%v", userId, prevOffset)),
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,b30,%v,%v,%v", viewerId, ownerId, prevOffset, before)),
 				Label:    "<",
 				Disabled: prevOffset < 0,
 			},
 			&discord.ButtonComponent{
-				CustomID: discord.ComponentID(fmt.Sprintf("%v,b30,%v", userId, nextOffset)),
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,b30,%v,%v,%v", viewerId, ownerId, nextOffset, before)),
 				Label:    ">",
 				Disabled: nextOffset >= count,
 			},
@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type editHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewEditHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *editHandler {
+	return &editHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+const timestampInputLayout = "2006-01-02 15:04:05"
+
+func (h *editHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	data := e.Data.(*discord.CommandInteraction)
+
+	st := h.store.Bot.State()
+
+	idStr := data.Options.Find("score_id").String()
+
+	id, ok := parseScoreId(idStr)
+	if !ok {
+		sendCommandErrorReply(st, fmt.Sprintf("Invalid score ID `%s`!", idStr), e)
+		return true
+	}
+
+	sess := database.GetSession(ctx)
+
+	scoresRepo := sess.GetScoresRepo()
+
+	currRecs, err := scoresRepo.GetById(ctx, id)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	if len(currRecs) == 0 || currRecs[0].UserId != int64(e.Sender().ID) {
+		sendCommandErrorReply(st, fmt.Sprintf("You don't have a score with ID `%s`!", idStr), e)
+		return true
+	}
+
+	currRec := currRecs[0]
+
+	loc, err := getUserLocation(ctx, int64(e.Sender().ID))
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	ccs := []discord.TopLevelComponent{
+		&discord.LabelComponent{
+			Label: "Score",
+			Component: &discord.TextInputComponent{
+				CustomID:     discord.ComponentID("edit_score_input"),
+				Style:        discord.TextInputShortStyle,
+				LengthLimits: [2]int{7, 8},
+				Required:     true,
+				Value:        strconv.Itoa(currRec.Score),
+			},
+		},
+		&discord.LabelComponent{
+			Label:       "Timestamp",
+			Description: fmt.Sprintf("In the format of YYYY-MM-DD hh:mm:ss, in your timezone (%s)", loc.String()),
+			Component: &discord.TextInputComponent{
+				CustomID:     discord.ComponentID("edit_timestamp_input"),
+				Style:        discord.TextInputShortStyle,
+				LengthLimits: [2]int{19, 19},
+				Required:     true,
+				Value:        time.UnixMilli(currRec.Timestamp).In(loc).Format(timestampInputLayout),
+			},
+		},
+	}
+
+	sendModalResponse(st, fmt.Sprintf("%v,edit_score,%v", e.Sender().ID, id), fmt.Sprintf("Edit score %v", id), ccs, e)
+
+	return true
+}
+
+func (h *editHandler) HandleEditModalSubmit(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	in := e.Data.(*discord.ModalInteraction)
+	val := in.CustomID
+
+	params := strings.Split(string(val), ",")
+
+	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	id, _ := strconv.ParseInt(params[2], 10, 64)
+
+	if userId != int64(e.Sender().ID) {
+		sendInteractionResponse(st, embedbuilder.UserError(fmt.Sprintf("You don't have a score with ID `%v`!", id)), []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	// workaround: .Find() does not seem to work for components that are nested.
+	scoreValue := in.Components[0].(*discord.LabelComponent).Component.(*discord.TextInputComponent).Value
+	timestampValue := in.Components[1].(*discord.LabelComponent).Component.(*discord.TextInputComponent).Value
+
+	score, errStr, ok := parseFullScore(scoreValue)
+	if !ok {
+		sendInteractionResponse(st, embedbuilder.UserError(errStr), []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	loc, err := getUserLocation(ctx, userId)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	ts, err := time.ParseInLocation(timestampInputLayout, strings.TrimSpace(timestampValue), loc)
+	if err != nil {
+		sendInteractionResponse(st, embedbuilder.UserError(fmt.Sprintf("Invalid timestamp `%s`, expecting the format YYYY-MM-DD hh:mm:ss!", timestampValue)), []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	sess := database.GetSession(ctx)
+
+	tx, err := sess.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	isCommit := false
+
+	defer func() {
+		if !isCommit {
+			err := tx.Rollback()
+			if err != nil {
+				logAndSendCommandError(ctx, st, err, e)
+			}
+		}
+	}()
+
+	scoresRepo := sess.GetScoresRepo()
+	editsRepo := sess.GetScoreEditsRepo()
+
+	// the score is looked up again, as it could have been unsaved while the modal was open.
+	currRecs, err := scoresRepo.GetById(ctx, id)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	if len(currRecs) == 0 || currRecs[0].UserId != userId {
+		sendInteractionResponse(st, embedbuilder.UserError(fmt.Sprintf("You don't have a score with ID `%v`!", id)), []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	currRec := currRecs[0]
+
+	// the input only has a precision of seconds, keep the original milliseconds if the timestamp is left untouched.
+	newTimestamp := ts.UnixMilli()
+	if time.UnixMilli(currRec.Timestamp).In(loc).Format(timestampInputLayout) == ts.Format(timestampInputLayout) {
+		newTimestamp = currRec.Timestamp
+	}
+
+	if currRec.Score == score && currRec.Timestamp == newTimestamp {
+		sendInteractionResponse(st, embedbuilder.UserError("Nothing was changed!"), []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	chart, song, ok := h.songdata.GetChartById(currRec.ChartId)
+	if !ok {
+		logAndSendCommandError(ctx, st, fmt.Errorf("chart id %v is not found in songdata", currRec.ChartId), e)
+		return true
+	}
+
+	// only the changed values are validated, so scores saved before the checks existed can still be edited.
+	if score != currRec.Score {
+		if msg, ok := getUnachievableScoreMessage(chart, score); ok {
+			sendInteractionResponse(st, embedbuilder.UserError(msg), []discord.TopLevelComponent{}, e)
+			return true
+		}
+	}
+
+	if newTimestamp != currRec.Timestamp {
+		errStr, ok := validatePlayedAt(chart, ts, time.Now())
+		if !ok {
+			sendInteractionResponse(st, embedbuilder.UserError(errStr), []discord.TopLevelComponent{}, e)
+			return true
+		}
+	}
+
+	_, err = scoresRepo.Update(ctx, id, score, newTimestamp)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	_, err = editsRepo.Insert(ctx, database.ScoreEditRecord{
+		ScoreId:      id,
+		UserId:       userId,
+		OldScore:     currRec.Score,
+		NewScore:     score,
+		OldTimestamp: currRec.Timestamp,
+		NewTimestamp: newTimestamp,
+		EditedAt:     time.Now().UnixMilli(),
+	})
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	isCommit = true
+
+	embed := discord.Embed{
+		Title: "Score edited",
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Song",
+				Value: fmt.Sprintf("%s - %s", song.EscapedTitle(), song.EscapedArtist()),
+			},
+			{
+				Name:  "Chart",
+				Value: fmt.Sprintf("%s - Lv%s (%s) (v%s)", chart.GetDiffDisplayName(), chart.Level, chart.GetCCString(), chart.Ver),
+			},
+			{
+				Name:   "Score",
+				Value:  fmt.Sprintf("%v ▸ **%v**", currRec.Score, score),
+				Inline: true,
+			},
+			{
+				Name:   "Play Rating",
+				Value:  fmt.Sprintf("%s ▸ **%s**", chart.GetScoreRatingString(currRec.Score), chart.GetScoreRatingString(score)),
+				Inline: true,
+			},
+			{
+				Name:   "Timestamp",
+				Value:  fmt.Sprintf("<t:%v:f> ▸ **<t:%v:f>**", currRec.Timestamp/1000, newTimestamp/1000),
+				Inline: true,
+			},
+		},
+		Footer: &discord.EmbedFooter{
+			Text: fmt.Sprintf("Score ID: %v", id),
+		},
+	}
+
+	sendInteractionResponse(st, embedbuilder.Info(embed), []discord.TopLevelComponent{}, e)
+
+	return true
+}
@@ -0,0 +1,335 @@
+package commands
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/sendpart"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/imagebuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type forecastHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewForecastHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *forecastHandler {
+	return &forecastHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+const (
+	// only the recent history is used for the trend, as progress tends to slow down over time.
+	forecastWindowDays = 180
+	forecastMinDays    = 5
+	forecastMinSpan    = 7
+	// the band covers roughly 95% of the slopes consistent with the history.
+	forecastConfidenceZ = 1.96
+	// forecasts further than this are not shown, as the trend is unlikely to hold for that long.
+	forecastMaxDays = 3 * 365
+)
+
+type forecastTrend struct {
+	Slope     float64
+	Intercept float64
+	SlopeErr  float64
+}
+
+type improvementCandidate struct {
+	Song      songdata.Song
+	Chart     songdata.Chart
+	Score     int
+	NextScore int
+	Gain      float64
+}
+
+func (h *forecastHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	data := e.Data.(*discord.CommandInteraction)
+
+	st := h.store.Bot.State()
+
+	target, err := data.Options.Find("target").FloatValue()
+	if err != nil || target <= 0 || target > 15 {
+		sendCommandErrorReply(st, fmt.Sprintf("Invalid target potential `%s`!", data.Options.Find("target").String()), e)
+		return true
+	}
+
+	sess := database.GetSession(ctx)
+
+	scores, err := sess.GetScoresRepo().GetByUser(ctx, int64(e.Sender().ID))
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	snapshots := computeB30Timeline(h.songdata, scores)
+	if len(snapshots) == 0 {
+		sendCommandErrorReply(st, "You don't have any scores saved!", e)
+		return true
+	}
+
+	current := snapshots[len(snapshots)-1].Potential
+
+	embed := discord.Embed{
+		Title: fmt.Sprintf("Forecast to Potential %.2f", target),
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Estimated potential",
+				Value: fmt.Sprintf("**%.4f** (%+.4f to go)", current, target-current),
+			},
+		},
+		Footer: &discord.EmbedFooter{
+			Text: "Estimated potential assumes your recent-10 equals your top 10 plays. The forecast assumes you keep improving at the same pace.",
+		},
+	}
+
+	if current >= target {
+		embed.Fields = append(embed.Fields, discord.EmbedField{
+			Name:  "Forecast",
+			Value: "You have already reached this potential!",
+		})
+		sendCommandReply(st, embedbuilder.Info(embed), e)
+		return true
+	}
+
+	embed.Fields = append(embed.Fields, discord.EmbedField{
+		Name:  "Charts to improve",
+		Value: getImprovementCandidatesDescription(h.songdata, scores),
+	})
+
+	days, values := getDailyPotentials(snapshots)
+	if len(days) < forecastMinDays || days[len(days)-1]-days[0] < forecastMinSpan {
+		embed.Fields = slices.Insert(embed.Fields, 1, discord.EmbedField{
+			Name:  "Forecast",
+			Value: fmt.Sprintf("Not enough history to forecast yet. Save scores on at least %v different days spanning %v days or more.", forecastMinDays, forecastMinSpan),
+		})
+		sendCommandReply(st, embedbuilder.Info(embed), e)
+		return true
+	}
+
+	trend := fitLinearTrend(days, values)
+	lastDay := days[len(days)-1]
+
+	embed.Fields = slices.Insert(embed.Fields, 1, discord.EmbedField{
+		Name:  "Forecast",
+		Value: getForecastDescription(trend, current, target, lastDay),
+	})
+
+	img, err := createForecastGraph(days, values, trend, current, target, lastDay)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	embed.Image = &discord.EmbedImage{
+		URL: "attachment://forecast.png",
+	}
+
+	files := []sendpart.File{
+		{Name: "forecast.png", Reader: bytes.NewReader(img)},
+	}
+
+	sendInteractionResponseWithFiles(st, embedbuilder.Info(embed), []discord.TopLevelComponent{}, files, e)
+
+	return true
+}
+
+// Returns the estimated potential at the end of each day with a save within the forecast window, with days given as
+// unix days.
+func getDailyPotentials(snapshots []b30Snapshot) ([]float64, []float64) {
+	days := make([]float64, 0)
+	values := make([]float64, 0)
+
+	lastDay := snapshots[len(snapshots)-1].Timestamp / 86400000
+
+	for _, s := range snapshots {
+		day := s.Timestamp / 86400000
+		if lastDay-day > forecastWindowDays {
+			continue
+		}
+
+		if len(days) > 0 && days[len(days)-1] == float64(day) {
+			values[len(values)-1] = s.Potential
+			continue
+		}
+
+		days = append(days, float64(day))
+		values = append(values, s.Potential)
+	}
+
+	return days, values
+}
+
+// Fits a least squares line, along with the standard error of its slope.
+func fitLinearTrend(xs []float64, ys []float64) forecastTrend {
+	n := float64(len(xs))
+
+	meanX, meanY := 0.0, 0.0
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	sxx, sxy := 0.0, 0.0
+	for i := range xs {
+		sxx += (xs[i] - meanX) * (xs[i] - meanX)
+		sxy += (xs[i] - meanX) * (ys[i] - meanY)
+	}
+
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	sse := 0.0
+	for i := range xs {
+		r := ys[i] - (intercept + slope*xs[i])
+		sse += r * r
+	}
+
+	return forecastTrend{
+		Slope:     slope,
+		Intercept: intercept,
+		SlopeErr:  math.Sqrt(sse / (n - 2) / sxx),
+	}
+}
+
+func getForecastDescription(trend forecastTrend, current float64, target float64, lastDay float64) string {
+	if trend.Slope <= 0 {
+		return "Your potential has not been going up recently, so there is no trend to project yet."
+	}
+
+	remaining := target - current
+	slopeHi := trend.Slope + forecastConfidenceZ*trend.SlopeErr
+	slopeLo := trend.Slope - forecastConfidenceZ*trend.SlopeErr
+
+	expected := remaining / trend.Slope
+	if expected > forecastMaxDays {
+		return fmt.Sprintf("At your recent pace of %+.4f per week, reaching the target would take more than %v years.", trend.Slope*7, forecastMaxDays/365)
+	}
+
+	earliest := remaining / slopeHi
+
+	latestStr := "no upper bound, as your progress has been uneven"
+	if slopeLo > 0 && remaining/slopeLo <= forecastMaxDays {
+		latestStr = fmt.Sprintf("<t:%v:D>", forecastDayToUnix(lastDay+remaining/slopeLo))
+	}
+
+	return fmt.Sprintf("Expected around **<t:%v:D>** (<t:%v:R>)\n95%% band: <t:%v:D> to %s\n-# Recent pace: %+.4f per week",
+		forecastDayToUnix(lastDay+expected),
+		forecastDayToUnix(lastDay+expected),
+		forecastDayToUnix(lastDay+earliest),
+		latestStr,
+		trend.Slope*7)
+}
+
+func forecastDayToUnix(day float64) int64 {
+	return int64(day * 86400)
+}
+
+// Lists the charts where reaching the next grade would raise the b30 the most. These are the charts the projection
+// assumes the improvement comes from.
+func getImprovementCandidatesDescription(sd *songdata.Service, scores []database.ScoreRecord) string {
+	best := getBestScoresByChart(scores)
+
+	ratings := make([]float64, 0, len(best))
+	for _, r := range getBestRatingsByChart(sd, scores) {
+		ratings = append(ratings, r)
+	}
+	slices.SortFunc(ratings, func(a, b float64) int {
+		return cmp.Compare(b, a)
+	})
+
+	// a chart needs to beat the 30th entry to enter the b30.
+	floor := 0.0
+	if len(ratings) >= 30 {
+		floor = ratings[29]
+	}
+
+	candidates := make([]improvementCandidate, 0)
+	for _, s := range best {
+		chart, song, ok := sd.GetChartById(s.ChartId)
+		if !ok || chart.CC == 0 {
+			continue
+		}
+
+		var next int
+		switch {
+		case s.Score < 9800000:
+			next = 9800000
+		case s.Score < 9900000:
+			next = 9900000
+		case s.Score < 10000000:
+			next = 10000000
+		default:
+			continue
+		}
+
+		gain := chart.GetActualScoreRating(next) - max(chart.GetActualScoreRating(s.Score), floor)
+		if gain <= 0 {
+			continue
+		}
+
+		candidates = append(candidates, improvementCandidate{Song: song, Chart: chart, Score: s.Score, NextScore: next, Gain: gain})
+	}
+
+	if len(candidates) == 0 {
+		return "None of your played charts can raise your b30 by reaching the next grade. New charts are needed to progress."
+	}
+
+	slices.SortFunc(candidates, func(a, b improvementCandidate) int {
+		return cmp.Compare(b.Gain, a.Gain)
+	})
+
+	candidatesBuilder := strings.Builder{}
+	for _, c := range candidates[:min(5, len(candidates))] {
+		fmt.Fprintf(&candidatesBuilder, "%s ▸ %s (%s): %v ▸ %v (%s), +%.4f\n", c.Song.EscapedAltTitle(), strings.ToUpper(c.Chart.Diff), c.Chart.GetCCString(), c.Score, c.NextScore, getScoreGrade(c.NextScore), c.Gain)
+	}
+
+	return candidatesBuilder.String()
+}
+
+func createForecastGraph(days []float64, values []float64, trend forecastTrend, current float64, target float64, lastDay float64) ([]byte, error) {
+	actualPoints := make([]imagebuilder.Point, 0, len(days))
+	for i := range days {
+		actualPoints = append(actualPoints, imagebuilder.Point{Time: time.Unix(forecastDayToUnix(days[i]), 0), Value: values[i]})
+	}
+
+	series := []imagebuilder.Series{
+		{Name: "Estimated potential", Color: imagebuilder.PrimaryColor, Points: actualPoints},
+	}
+
+	if trend.Slope > 0 {
+		endDay := lastDay + min((target-current)/trend.Slope, forecastMaxDays)
+		series = append(series, imagebuilder.Series{
+			Name:  "Trend",
+			Color: imagebuilder.SecondaryColor,
+			Points: []imagebuilder.Point{
+				{Time: time.Unix(forecastDayToUnix(days[0]), 0), Value: trend.Intercept + trend.Slope*days[0]},
+				{Time: time.Unix(forecastDayToUnix(endDay), 0), Value: trend.Intercept + trend.Slope*endDay},
+			},
+		})
+	}
+
+	return imagebuilder.RenderLineChart(imagebuilder.LineChart{
+		Title:       fmt.Sprintf("Forecast to %.2f", target),
+		Series:      series,
+		ValueFormat: "%.2f",
+	})
+}
@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/sendpart"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/imagebuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type historyHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewHistoryHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *historyHandler {
+	return &historyHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+type b30Snapshot struct {
+	Timestamp int64
+	B30       float64
+	Potential float64
+}
+
+func (h *historyHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	data := e.Data.(*discord.CommandInteraction)
+
+	st := h.store.Bot.State()
+
+	sess := database.GetSession(ctx)
+
+	loc, err := getUserLocation(ctx, int64(e.Sender().ID))
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	from, to, errStr, ok := parseDateRangeOptions(data.Options, loc)
+	if !ok {
+		sendCommandErrorReply(st, errStr, e)
+		return true
+	}
+
+	scoresRepo := sess.GetScoresRepo()
+
+	scores, err := scoresRepo.GetByUser(ctx, int64(e.Sender().ID))
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	if len(scores) == 0 {
+		sendCommandErrorReply(st, "You don't have any scores saved!", e)
+		return true
+	}
+
+	// snapshots before the range are still computed, as every snapshot depends on all the scores saved before it.
+	snapshots := computeB30Timeline(h.songdata, scores)
+	snapshots = slices.DeleteFunc(snapshots, func(s b30Snapshot) bool {
+		return s.Timestamp < from || s.Timestamp >= to
+	})
+
+	if len(snapshots) == 0 {
+		sendCommandErrorReply(st, "You don't have any scores saved in this date range!", e)
+		return true
+	}
+
+	b30Points := make([]imagebuilder.Point, 0, len(snapshots))
+	pttPoints := make([]imagebuilder.Point, 0, len(snapshots))
+	for _, s := range snapshots {
+		t := time.UnixMilli(s.Timestamp)
+		b30Points = append(b30Points, imagebuilder.Point{Time: t, Value: s.B30})
+		pttPoints = append(pttPoints, imagebuilder.Point{Time: t, Value: s.Potential})
+	}
+
+	img, err := imagebuilder.RenderLineChart(imagebuilder.LineChart{
+		Title: "Potential History",
+		Series: []imagebuilder.Series{
+			{Name: "Best-30 average", Color: imagebuilder.PrimaryColor, Points: b30Points},
+			{Name: "Estimated potential", Color: imagebuilder.SecondaryColor, Points: pttPoints},
+		},
+		ValueFormat: "%.2f",
+		Location:    loc,
+	})
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	first := snapshots[0]
+	last := snapshots[len(snapshots)-1]
+
+	embed := discord.Embed{
+		Title: "Potential History from Saved Scores",
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Range",
+				Value: fmt.Sprintf("<t:%v:d> - <t:%v:d> (%v saves)", first.Timestamp/1000, last.Timestamp/1000, len(snapshots)),
+			},
+			{
+				Name:   "Best-30 average",
+				Value:  fmt.Sprintf("%.4f ▸ **%.4f** (%+.4f)", first.B30, last.B30, last.B30-first.B30),
+				Inline: true,
+			},
+			{
+				Name:   "Estimated potential",
+				Value:  fmt.Sprintf("%.4f ▸ **%.4f** (%+.4f)", first.Potential, last.Potential, last.Potential-first.Potential),
+				Inline: true,
+			},
+		},
+		Image: &discord.EmbedImage{
+			URL: "attachment://history.png",
+		},
+		Footer: &discord.EmbedFooter{
+			Text: "Estimated potential assumes your recent-10 equals your top 10 plays.",
+		},
+	}
+
+	files := []sendpart.File{
+		{Name: "history.png", Reader: bytes.NewReader(img)},
+	}
+
+	sendInteractionResponseWithFiles(st, embedbuilder.Info(embed), []discord.TopLevelComponent{}, files, e)
+
+	return true
+}
+
+// Computes the best-30 average and estimated potential right after each of the given scores is saved. The scores are
+// expected to be sorted by timestamp.
+func computeB30Timeline(sd *songdata.Service, scores []database.ScoreRecord) []b30Snapshot {
+	bestRatings := make(map[int]float64)
+	res := make([]b30Snapshot, 0, len(scores))
+
+	for _, s := range scores {
+		chart, _, ok := sd.GetChartById(s.ChartId)
+		if !ok {
+			continue
+		}
+
+		rating := chart.GetActualScoreRating(s.Score)
+		if best, ok := bestRatings[s.ChartId]; !ok || rating > best {
+			bestRatings[s.ChartId] = rating
+		}
+
+		b30, ptt := getB30Summary(bestRatings)
+		res = append(res, b30Snapshot{
+			Timestamp: s.Timestamp,
+			B30:       b30,
+			Potential: ptt,
+		})
+	}
+
+	return res
+}
+
+// Returns the best rating of each chart among the given scores.
+func getBestRatingsByChart(sd *songdata.Service, scores []database.ScoreRecord) map[int]float64 {
+	bestRatings := make(map[int]float64)
+
+	for _, s := range scores {
+		chart, _, ok := sd.GetChartById(s.ChartId)
+		if !ok {
+			continue
+		}
+
+		rating := chart.GetActualScoreRating(s.Score)
+		if best, ok := bestRatings[s.ChartId]; !ok || rating > best {
+			bestRatings[s.ChartId] = rating
+		}
+	}
+
+	return bestRatings
+}
+
+// Returns the best-30 average and the estimated potential from the best rating of each chart. The potential is
+// estimated by assuming the recent-10 plays are the same as the top 10 best plays.
+func getB30Summary(bestRatings map[int]float64) (float64, float64) {
+	ratings := make([]float64, 0, len(bestRatings))
+	for _, r := range bestRatings {
+		ratings = append(ratings, r)
+	}
+
+	slices.SortFunc(ratings, func(a, b float64) int {
+		return cmp.Compare(b, a)
+	})
+
+	b30Sum := 0.0
+	r10Sum := 0.0
+	count := 0
+	for i, r := range ratings {
+		if i >= 30 {
+			break
+		}
+		b30Sum += r
+		if i < 10 {
+			r10Sum += r
+		}
+		count++
+	}
+
+	if count == 0 {
+		return 0, 0
+	}
+
+	// the average matches /b30, which averages over the charts played when there are less than 30 of them. the
+	// potential follows the game, which always divides by 40.
+	return b30Sum / float64(count), (b30Sum + r10Sum) / 40
+}
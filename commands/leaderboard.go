@@ -0,0 +1,177 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type leaderboardHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewLeaderboardHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *leaderboardHandler {
+	return &leaderboardHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+func (h *leaderboardHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	data := e.Data.(*discord.CommandInteraction)
+
+	st := h.store.Bot.State()
+
+	query := data.Options.Find("song").String()
+	matched := h.songdata.Search(query, 1)
+	if len(matched) == 0 {
+		sendSongQueryCommandError(st, query, e)
+		return true
+	}
+
+	song := matched[0]
+
+	diffKey := data.Options.Find("diff").String()
+	chart, ok := song.GetChart(diffKey)
+	if !ok {
+		sendDiffNotExistCommandError(st, diffKey, song.EscapedAltTitle(), e)
+		return true
+	}
+
+	sess := database.GetSession(ctx)
+
+	userId := int64(e.Sender().ID)
+
+	embed, components, err := createLeaderboardPage(ctx, sess.GetLeaderboardsRepo(), song, chart, int64(e.GuildID), userId, 0)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
+
+	return true
+}
+
+func (h *leaderboardHandler) HandleLeaderboardPageSelect(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	val := e.Data.(*discord.ButtonInteraction).CustomID
+
+	params := strings.Split(string(val), ",")
+
+	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	chartId, _ := strconv.Atoi(params[2])
+	offset, _ := strconv.Atoi(params[3])
+
+	chart, song, _ := h.songdata.GetChartById(chartId)
+
+	sess := database.GetSession(ctx)
+
+	embed, components, err := createLeaderboardPage(ctx, sess.GetLeaderboardsRepo(), song, chart, int64(e.GuildID), userId, offset)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	resp := api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Embeds:     &[]discord.Embed{embedbuilder.Info(embed)},
+			Components: (*discord.TopLevelComponents)(&components),
+		},
+	}
+
+	st.RespondInteraction(e.ID, e.Token, resp)
+
+	return true
+}
+
+func createLeaderboardPage(ctx context.Context, repo *database.LeaderboardsRepo, song songdata.Song, chart songdata.Chart, guildId int64, userId int64, offset int) (discord.Embed, []discord.TopLevelComponent, error) {
+	count, err := repo.GetChartLeaderboardCount(ctx, guildId, chart.Id)
+	if err != nil {
+		return discord.Embed{}, nil, err
+	}
+
+	entries, err := repo.GetChartLeaderboardWithOffset(ctx, guildId, chart.Id, offset, 10)
+	if err != nil {
+		return discord.Embed{}, nil, err
+	}
+
+	// the caller is looked up separately, so their rank is shown even when it is not on the current page.
+	own, err := repo.GetChartLeaderboardEntryByUser(ctx, guildId, chart.Id, userId)
+	if err != nil {
+		return discord.Embed{}, nil, err
+	}
+
+	embed := createLeaderboardEmbed(song, chart, entries, own, count)
+	components := createLeaderboardPageButtons(userId, chart.Id, count, offset/10)
+
+	return embed, components, nil
+}
+
+func createLeaderboardEmbed(song songdata.Song, chart songdata.Chart, entries []database.LeaderboardEntry, own []database.LeaderboardEntry, count int) discord.Embed {
+	rankingBuilder := strings.Builder{}
+
+	if len(entries) == 0 {
+		rankingBuilder.WriteString("No one in this server has a score ranked for this chart yet.")
+	}
+
+	for _, s := range entries {
+		fmt.Fprintf(&rankingBuilder, "%v. <@%v> - %v (Play Rating %s) <t:%v:d>\n", s.Rank, s.UserId, s.Score, chart.GetScoreRatingString(s.Score), s.Timestamp/1000)
+	}
+
+	ownValue := "You are not ranked for this chart. Save a score and use `/settings rankings:True` to join the leaderboards of this server."
+	if len(own) > 0 {
+		s := own[0]
+		ownValue = fmt.Sprintf("#%v of %v - %v (Play Rating %s) <t:%v:d>", s.Rank, count, s.Score, chart.GetScoreRatingString(s.Score), s.Timestamp/1000)
+	}
+
+	embed := discord.Embed{
+		Title: fmt.Sprintf("Leaderboard for %s ▸ %s Lv%s", song.EscapedAltTitle(), chart.GetDiffDisplayName(), chart.Level),
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Ranking",
+				Value: rankingBuilder.String(),
+			},
+			{
+				Name:  "Your rank",
+				Value: ownValue,
+			},
+		},
+	}
+
+	return embed
+}
+
+func createLeaderboardPageButtons(userId int64, chartId int, count int, pageIdx int) []discord.TopLevelComponent {
+	prevOffset := (pageIdx - 1) * 10
+	nextOffset := (pageIdx + 1) * 10
+
+	return []discord.TopLevelComponent{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,leaderboard,%v,%v", userId, chartId, prevOffset)),
+				Label:    "<",
+				Disabled: prevOffset < 0,
+			},
+			&discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,leaderboard,%v,%v", userId, chartId, nextOffset)),
+				Label:    ">",
+				Disabled: nextOffset >= count,
+			},
+		},
+	}
+}
@@ -0,0 +1,223 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type practiceSessionHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewPracticeSessionHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *practiceSessionHandler {
+	return &practiceSessionHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+func (h *practiceSessionHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	data := e.Data.(*discord.CommandInteraction)
+
+	if len(data.Options) == 0 {
+		return false
+	}
+
+	st := h.store.Bot.State()
+
+	sess := database.GetSession(ctx)
+
+	practiceSessionsRepo := sess.GetPracticeSessionsRepo()
+	userId := int64(e.Sender().ID)
+
+	openSessions, err := practiceSessionsRepo.GetOpenByUser(ctx, userId)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	switch data.Options[0].Name {
+	case "start":
+		if len(openSessions) > 0 {
+			sendCommandErrorReply(st, fmt.Sprintf("You already have a session in progress, started <t:%v:R>! Send `/session end` to end it first.", openSessions[0].StartedAt/1000), e)
+			return true
+		}
+
+		now := time.Now()
+
+		_, err := practiceSessionsRepo.Start(ctx, userId, now.UnixMilli())
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		embed := discord.Embed{
+			Title:       "Session started",
+			Description: fmt.Sprintf("Scores saved from now on are grouped into this session. Send `/session end` to end it and get a summary.\nStarted <t:%v:R>", now.Unix()),
+		}
+
+		sendCommandReply(st, embedbuilder.Info(embed), e)
+	case "end":
+		if len(openSessions) == 0 {
+			sendCommandErrorReply(st, "You don't have a session in progress! Send `/session start` to start one.", e)
+			return true
+		}
+
+		openSession := openSessions[0]
+		now := time.Now()
+
+		_, err := practiceSessionsRepo.End(ctx, openSession.Id, now.UnixMilli())
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		scoresRepo := sess.GetScoresRepo()
+
+		sessionScores, err := scoresRepo.GetByPracticeSession(ctx, openSession.Id)
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		allScores, err := scoresRepo.GetByUser(ctx, userId)
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		embed := createPracticeSessionSummaryEmbed(h.songdata, openSession, now, sessionScores, allScores)
+		sendCommandReply(st, embedbuilder.Info(embed), e)
+	default:
+		return false
+	}
+
+	return true
+}
+
+func createPracticeSessionSummaryEmbed(sd *songdata.Service, practiceSession database.PracticeSessionRecord, endedAt time.Time, sessionScores []database.ScoreRecord, allScores []database.ScoreRecord) discord.Embed {
+	embed := discord.Embed{
+		Title:       "Session summary",
+		Description: fmt.Sprintf("<t:%v:f> to <t:%v:f>", practiceSession.StartedAt/1000, endedAt.Unix()),
+	}
+
+	if len(sessionScores) == 0 {
+		embed.Fields = []discord.EmbedField{
+			{
+				Name:  "Plays",
+				Value: "No scores were saved during this session.",
+			},
+		}
+		return embed
+	}
+
+	// the state before the session is everything saved outside of it, so scores saved or edited afterwards are
+	// counted the same way as in /b30.
+	sessionIds := make(map[int64]bool, len(sessionScores))
+	for _, s := range sessionScores {
+		sessionIds[s.Id] = true
+	}
+
+	prevScores := slices.DeleteFunc(slices.Clone(allScores), func(s database.ScoreRecord) bool {
+		return sessionIds[s.Id]
+	})
+
+	prevBest := getBestScoresByChart(prevScores)
+	sessionBest := getBestScoresByChart(sessionScores)
+
+	pbBuilder := strings.Builder{}
+	pbCount := 0
+	for _, s := range sessionScores {
+		if sessionBest[s.ChartId].Id != s.Id {
+			continue
+		}
+
+		chart, song, ok := sd.GetChartById(s.ChartId)
+		if !ok {
+			continue
+		}
+
+		prev, played := prevBest[s.ChartId]
+		if played && s.Score <= prev.Score {
+			continue
+		}
+
+		// only the first few are listed to stay within the field length limit.
+		pbCount++
+		if pbCount > 10 {
+			continue
+		}
+
+		if !played {
+			fmt.Fprintf(&pbBuilder, "%s ▸ %s - %v (first play)\n", song.EscapedAltTitle(), strings.ToUpper(chart.Diff), s.Score)
+		} else {
+			fmt.Fprintf(&pbBuilder, "%s ▸ %s - %v ▸ **%v** (+%v)\n", song.EscapedAltTitle(), strings.ToUpper(chart.Diff), prev.Score, s.Score, s.Score-prev.Score)
+		}
+	}
+
+	if pbCount > 10 {
+		fmt.Fprintf(&pbBuilder, "...and %v more\n", pbCount-10)
+	}
+
+	pbValue := pbBuilder.String()
+	if pbValue == "" {
+		pbValue = "No new personal bests this time."
+	}
+
+	prevB30, _ := getB30Summary(getBestRatingsByChart(sd, prevScores))
+	currB30, _ := getB30Summary(getBestRatingsByChart(sd, allScores))
+
+	var bestPlay database.ScoreRecord
+	bestRating := -1.0
+	for _, s := range sessionScores {
+		chart, _, ok := sd.GetChartById(s.ChartId)
+		if !ok {
+			continue
+		}
+
+		rating := chart.GetActualScoreRating(s.Score)
+		if rating > bestRating {
+			bestPlay = s
+			bestRating = rating
+		}
+	}
+
+	bestPlayValue := "-"
+	if chart, song, ok := sd.GetChartById(bestPlay.ChartId); ok && bestRating >= 0 {
+		bestPlayValue = fmt.Sprintf("%s ▸ %s Lv%s - %v (Play Rating %s)", song.EscapedAltTitle(), chart.GetDiffDisplayName(), chart.Level, bestPlay.Score, chart.GetScoreRatingString(bestPlay.Score))
+	}
+
+	embed.Fields = []discord.EmbedField{
+		{
+			Name:  "Plays",
+			Value: fmt.Sprintf("%v scores saved on %v charts", len(sessionScores), len(sessionBest)),
+		},
+		{
+			Name:  "New personal bests",
+			Value: pbValue,
+		},
+		{
+			Name:  "b30 average",
+			Value: fmt.Sprintf("%.4f ▸ **%.4f** (%+.4f)", prevB30, currB30, currB30-prevB30),
+		},
+		{
+			Name:  "Best play",
+			Value: bestPlayValue,
+		},
+	}
+
+	return embed
+}
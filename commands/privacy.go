@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/diamondburned/arikawa/v3/utils/httputil"
+	"github.com/lilacse/kagura/database"
+)
+
+// Returns the user given in the "user" option, or the sender if the option is not given.
+func getTargetUser(data *discord.CommandInteraction, e *gateway.InteractionCreateEvent) discord.User {
+	opt := data.Options.Find("user")
+	if opt.Name == "" {
+		return *e.Sender()
+	}
+
+	id, err := opt.SnowflakeValue()
+	if err != nil {
+		return *e.Sender()
+	}
+
+	user, ok := data.Resolved.Users[discord.UserID(id)]
+	if !ok {
+		return discord.User{ID: discord.UserID(id)}
+	}
+
+	return user
+}
+
+// Returns whether the viewer is allowed to see the saved scores of the owner, based on the privacy setting of the
+// owner and the guild the interaction happened in.
+func canViewScores(ctx context.Context, st *state.State, settingsRepo *database.UserSettingsRepo, ownerId int64, viewerId int64, guildId discord.GuildID) (bool, error) {
+	if ownerId == viewerId {
+		return true, nil
+	}
+
+	settings, err := settingsRepo.Get(ctx, ownerId)
+	if err != nil {
+		return false, err
+	}
+
+	switch settings.Privacy {
+	case database.PrivacyPublic:
+		return true, nil
+	case database.PrivacyGuild:
+		if !guildId.IsValid() {
+			return false, nil
+		}
+		return isGuildMember(st, guildId, discord.UserID(ownerId))
+	default:
+		return false, nil
+	}
+}
+
+func isGuildMember(st *state.State, guildId discord.GuildID, userId discord.UserID) (bool, error) {
+	_, err := st.Member(guildId, userId)
+	if err == nil {
+		return true, nil
+	}
+
+	var httpErr *httputil.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Status == http.StatusNotFound {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Notes whose scores are shown in the embed when the viewer is looking at the scores of another user.
+func describeScoresOwner(embed discord.Embed, ownerId int64, viewerId int64) discord.Embed {
+	if ownerId != viewerId {
+		embed.Description = fmt.Sprintf("Saved scores of <@%v>", ownerId)
+	}
+
+	return embed
+}
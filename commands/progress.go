@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type progressHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewProgressHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *progressHandler {
+	return &progressHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+type progressEntry struct {
+	Song   songdata.Song
+	Chart  songdata.Chart
+	Best   database.ScoreRecord
+	Played bool
+}
+
+func (h *progressHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	data := e.Data.(*discord.CommandInteraction)
+
+	st := h.store.Bot.State()
+
+	level := data.Options.Find("level").String()
+	diffKey := data.Options.Find("diff").String()
+
+	threshold := gradeThresholds["EX"]
+	thresholdStr := data.Options.Find("threshold").String()
+	if thresholdStr != "" {
+		score, errStr, ok := parseScoreThreshold(thresholdStr)
+		if !ok {
+			sendCommandErrorReply(st, errStr, e)
+			return true
+		}
+		threshold = score
+	}
+
+	sess := database.GetSession(ctx)
+
+	userId := int64(e.Sender().ID)
+
+	entries, err := getProgressEntries(ctx, h, sess.GetScoresRepo(), userId, level, diffKey)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	if len(entries) == 0 {
+		sendCommandErrorReply(st, "No charts found with the given level and difficulty!", e)
+		return true
+	}
+
+	embed := createProgressEmbed(entries, level, diffKey, threshold, 0)
+	components := createProgressPageButtons(userId, level, diffKey, threshold, len(entries), 0)
+
+	sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
+
+	return true
+}
+
+func (h *progressHandler) HandleProgressPageSelect(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	val := e.Data.(*discord.ButtonInteraction).CustomID
+
+	params := strings.Split(string(val), ",")
+
+	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	level := params[2]
+	diffKey := params[3]
+	threshold, _ := strconv.Atoi(params[4])
+	offset, _ := strconv.Atoi(params[5])
+
+	pageIdx := offset / 10
+
+	sess := database.GetSession(ctx)
+
+	entries, err := getProgressEntries(ctx, h, sess.GetScoresRepo(), userId, level, diffKey)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	embed := createProgressEmbed(entries, level, diffKey, threshold, offset)
+	components := createProgressPageButtons(userId, level, diffKey, threshold, len(entries), pageIdx)
+
+	resp := api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Embeds:     &[]discord.Embed{embedbuilder.Info(embed)},
+			Components: (*discord.TopLevelComponents)(&components),
+		},
+	}
+
+	st.RespondInteraction(e.ID, e.Token, resp)
+
+	return true
+}
+
+// Returns every chart of the level, optionally of a difficulty, with the best score of the user on it. The charts are
+// sorted by cc, then by title.
+func getProgressEntries(ctx context.Context, h *progressHandler, repo *database.ScoresRepo, userId int64, level string, diffKey string) ([]progressEntry, error) {
+	scores, err := repo.GetByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	best := getBestScoresByChart(scores)
+
+	entries := make([]progressEntry, 0)
+
+	for _, song := range h.songdata.GetData() {
+		for _, chart := range song.Charts {
+			if chart.Level != level || (diffKey != "" && chart.Diff != diffKey) {
+				continue
+			}
+
+			s, played := best[chart.Id]
+			entries = append(entries, progressEntry{Song: song, Chart: chart, Best: s, Played: played})
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b progressEntry) int {
+		return cmp.Or(cmp.Compare(a.Chart.CC, b.Chart.CC), cmp.Compare(a.Song.AltTitle, b.Song.AltTitle))
+	})
+
+	return entries, nil
+}
+
+func createProgressEmbed(entries []progressEntry, level string, diffKey string, threshold int, idx int) discord.Embed {
+	done := 0
+	for _, en := range entries {
+		if en.Played && en.Best.Score >= threshold {
+			done++
+		}
+	}
+
+	listBuilder := strings.Builder{}
+
+	for _, en := range entries[idx:min(idx+10, len(entries))] {
+		switch {
+		case !en.Played:
+			fmt.Fprintf(&listBuilder, "○ %s ▸ %s (%s) - Unplayed\n", en.Song.EscapedAltTitle(), strings.ToUpper(en.Chart.Diff), en.Chart.GetCCString())
+		case en.Best.Score >= threshold:
+			fmt.Fprintf(&listBuilder, "✓ %s ▸ %s (%s) - **%v**\n", en.Song.EscapedAltTitle(), strings.ToUpper(en.Chart.Diff), en.Chart.GetCCString(), en.Best.Score)
+		default:
+			fmt.Fprintf(&listBuilder, "✗ %s ▸ %s (%s) - %v\n", en.Song.EscapedAltTitle(), strings.ToUpper(en.Chart.Diff), en.Chart.GetCCString(), en.Best.Score)
+		}
+	}
+
+	title := fmt.Sprintf("Progress for Lv%s", level)
+	if diffKey != "" {
+		title = fmt.Sprintf("Progress for %s Lv%s", getFullDiffName(diffKey), level)
+	}
+
+	embed := discord.Embed{
+		Title: title,
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Completion",
+				Value: fmt.Sprintf("**%.2f%%** (%v / %v charts at %v or above)", float64(done)*100/float64(len(entries)), done, len(entries), threshold),
+			},
+			{
+				Name:  "Charts",
+				Value: listBuilder.String(),
+			},
+		},
+	}
+
+	return embed
+}
+
+func createProgressPageButtons(userId int64, level string, diffKey string, threshold int, count int, pageIdx int) []discord.TopLevelComponent {
+	prevOffset := (pageIdx - 1) * 10
+	nextOffset := (pageIdx + 1) * 10
+
+	return []discord.TopLevelComponent{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,progress,%s,%s,%v,%v", userId, level, diffKey, threshold, prevOffset)),
+				Label:    "<",
+				Disabled: prevOffset < 0,
+			},
+			&discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,progress,%s,%s,%v,%v", userId, level, diffKey, threshold, nextOffset)),
+				Label:    ">",
+				Disabled: nextOffset >= count,
+			},
+		},
+	}
+}
@@ -25,18 +25,7 @@ func NewPttHandler(store *store.Store, songdata *songdata.Service) *pttHandler {
 }
 
 func (h *pttHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
-	var data *discord.CommandInteraction
-
-	switch e.Data.(type) {
-	case *discord.CommandInteraction:
-		data = e.Data.(*discord.CommandInteraction)
-	default:
-		return false
-	}
-
-	if data.Name != "ptt" {
-		return false
-	}
+	data := e.Data.(*discord.CommandInteraction)
 
 	st := h.store.Bot.State()
 
@@ -91,6 +80,10 @@ func (h *pttHandler) HandleSlashCommand(ctx context.Context, e *gateway.Interact
 		},
 	}
 
+	if msg, ok := getUnachievableScoreMessage(chart, score); ok && isFullScoreInput(data.Options.Find("score").String()) {
+		embed.Description = fmt.Sprintf("-# %s", msg)
+	}
+
 	res := embedbuilder.Info(embed)
 	sendCommandReply(st, res, e)
 
@@ -26,18 +26,7 @@ func NewRandomHandler(store *store.Store, songdata *songdata.Service) *randomHan
 }
 
 func (h *randomHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
-	var data *discord.CommandInteraction
-
-	switch e.Data.(type) {
-	case *discord.CommandInteraction:
-		data = e.Data.(*discord.CommandInteraction)
-	default:
-		return false
-	}
-
-	if data.Name != "random" {
-		return false
-	}
+	data := e.Data.(*discord.CommandInteraction)
 
 	st := h.store.Bot.State()
 
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type rankingHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewRankingHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *rankingHandler {
+	return &rankingHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+func (h *rankingHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	sess := database.GetSession(ctx)
+
+	embed, components, err := createRankingPage(ctx, h, sess.GetGuildRankingsRepo(), int64(e.GuildID), int64(e.Sender().ID), 0)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
+
+	return true
+}
+
+func (h *rankingHandler) HandleRankingPageSelect(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	val := e.Data.(*discord.ButtonInteraction).CustomID
+
+	params := strings.Split(string(val), ",")
+
+	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	offset, _ := strconv.Atoi(params[2])
+
+	sess := database.GetSession(ctx)
+
+	embed, components, err := createRankingPage(ctx, h, sess.GetGuildRankingsRepo(), int64(e.GuildID), userId, offset)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	resp := api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Embeds:     &[]discord.Embed{embedbuilder.Info(embed)},
+			Components: (*discord.TopLevelComponents)(&components),
+		},
+	}
+
+	st.RespondInteraction(e.ID, e.Token, resp)
+
+	return true
+}
+
+func createRankingPage(ctx context.Context, h *rankingHandler, repo *database.GuildRankingsRepo, guildId int64, userId int64, offset int) (discord.Embed, []discord.TopLevelComponent, error) {
+	// the rankings of the whole guild are computed at once, so the queries do not grow with the number of members.
+	curr, err := repo.GetB30Rankings(ctx, guildId, math.MaxInt64)
+	if err != nil {
+		return discord.Embed{}, nil, err
+	}
+
+	prev, err := repo.GetB30Rankings(ctx, guildId, time.Now().AddDate(0, 0, -7).UnixMilli())
+	if err != nil {
+		return discord.Embed{}, nil, err
+	}
+
+	prevRatings := make(map[int64]float64, len(prev))
+	for _, r := range prev {
+		prevRatings[r.UserId] = r.AvgRating
+	}
+
+	embed := createRankingEmbed(h, curr, prevRatings, userId, offset)
+	components := createRankingPageButtons(userId, len(curr), offset/10)
+
+	return embed, components, nil
+}
+
+func createRankingEmbed(h *rankingHandler, rankings []database.GuildRankingRecord, prevRatings map[int64]float64, userId int64, offset int) discord.Embed {
+	rankingBuilder := strings.Builder{}
+
+	if len(rankings) == 0 {
+		rankingBuilder.WriteString("No one in this server has joined the rankings yet.")
+	}
+
+	ownValue := "You are not ranked in this server. Save a score and use `/settings rankings:True` to join the rankings of this server."
+
+	for i, r := range rankings {
+		if r.UserId == userId {
+			ownValue = fmt.Sprintf("#%v of %v - **%.4f** (%s)", i+1, len(rankings), r.AvgRating, getRankingChangeString(r, prevRatings))
+		}
+
+		if i < offset || i >= offset+10 {
+			continue
+		}
+
+		fmt.Fprintf(&rankingBuilder, "%v. <@%v> - **%.4f** (%s)\n", i+1, r.UserId, r.AvgRating, getRankingChangeString(r, prevRatings))
+
+		chart, song, ok := h.songdata.GetChartById(r.TopChartId)
+		if ok {
+			fmt.Fprintf(&rankingBuilder, "  -# Top: %s ▸ %s Lv%s - %v (Play Rating %.4f)\n", song.EscapedAltTitle(), chart.GetDiffDisplayName(), chart.Level, r.TopScore, r.TopRating)
+		}
+	}
+
+	// the ranking goes in the description, as a page can be longer than the 1024 characters allowed in a field.
+	embed := discord.Embed{
+		Title:       "Server b30 Ranking",
+		Description: rankingBuilder.String(),
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Your rank",
+				Value: ownValue,
+			},
+		},
+		Footer: &discord.EmbedFooter{
+			Text: "Changes are compared to the rankings from 7 days ago.",
+		},
+	}
+
+	return embed
+}
+
+func getRankingChangeString(r database.GuildRankingRecord, prevRatings map[int64]float64) string {
+	prev, ok := prevRatings[r.UserId]
+	if !ok {
+		return "new"
+	}
+
+	diff := r.AvgRating - prev
+	switch {
+	case diff > 0.00005:
+		return fmt.Sprintf("▲%.4f", diff)
+	case diff < -0.00005:
+		return fmt.Sprintf("▼%.4f", -diff)
+	default:
+		return "±0"
+	}
+}
+
+func createRankingPageButtons(userId int64, count int, pageIdx int) []discord.TopLevelComponent {
+	prevOffset := (pageIdx - 1) * 10
+	nextOffset := (pageIdx + 1) * 10
+
+	return []discord.TopLevelComponent{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,ranking,%v", userId, prevOffset)),
+				Label:    "<",
+				Disabled: prevOffset < 0,
+			},
+			&discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,ranking,%v", userId, nextOffset)),
+				Label:    ">",
+				Disabled: nextOffset >= count,
+			},
+		},
+	}
+}
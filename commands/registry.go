@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/gateway"
+)
+
+// Handles an interaction, returning whether it was handled.
+type InteractionHandler func(ctx context.Context, e *gateway.InteractionCreateEvent) bool
+
+// Defines a slash command along with the routes of every interaction that belongs to it.
+type Command struct {
+	Schema  api.CreateCommandData
+	Handler InteractionHandler
+	// handlers of the buttons of the command, keyed by the receiver in their custom IDs.
+	Components map[string]InteractionHandler
+	// handlers of the modals of the command, keyed by the receiver in their custom IDs.
+	Modals map[string]InteractionHandler
+	// handler of the autocomplete options of the command, nil if it has none.
+	Autocomplete InteractionHandler
+	// whether the command can only be used in servers.
+	GuildOnly bool
+	// whether the handlers of the command are given a database session in their context.
+	UsesDatabase bool
+}
+
+// A handler along with the command it belongs to.
+type Route struct {
+	Command Command
+	Handler InteractionHandler
+}
+
+type Registry struct {
+	commands        []Command
+	commandMap      map[string]Route
+	componentMap    map[string]Route
+	modalMap        map[string]Route
+	autocompleteMap map[string]Route
+}
+
+// Builds the routes of the given commands. Returns an error if a command name or receiver is registered twice.
+func NewRegistry(cmds []Command) (*Registry, error) {
+	r := Registry{
+		commands:        cmds,
+		commandMap:      make(map[string]Route),
+		componentMap:    make(map[string]Route),
+		modalMap:        make(map[string]Route),
+		autocompleteMap: make(map[string]Route),
+	}
+
+	for _, c := range cmds {
+		name := c.Schema.Name
+		if _, ok := r.commandMap[name]; ok {
+			return nil, fmt.Errorf("command %s is registered more than once", name)
+		}
+		r.commandMap[name] = Route{Command: c, Handler: c.Handler}
+
+		for receiver, h := range c.Components {
+			if _, ok := r.componentMap[receiver]; ok {
+				return nil, fmt.Errorf("component receiver %s of command %s is registered more than once", receiver, name)
+			}
+			r.componentMap[receiver] = Route{Command: c, Handler: h}
+		}
+
+		for receiver, h := range c.Modals {
+			if _, ok := r.modalMap[receiver]; ok {
+				return nil, fmt.Errorf("modal receiver %s of command %s is registered more than once", receiver, name)
+			}
+			r.modalMap[receiver] = Route{Command: c, Handler: h}
+		}
+
+		if c.Autocomplete != nil {
+			r.autocompleteMap[name] = Route{Command: c, Handler: c.Autocomplete}
+		}
+	}
+
+	return &r, nil
+}
+
+// Returns the schemas of every command, with commands that can only be used in servers hidden from DMs.
+func (r *Registry) GetSchemas() []api.CreateCommandData {
+	schemas := make([]api.CreateCommandData, 0, len(r.commands))
+	for _, c := range r.commands {
+		schema := c.Schema
+		schema.NoDMPermission = c.GuildOnly
+		schemas = append(schemas, schema)
+	}
+
+	return schemas
+}
+
+func (r *Registry) GetCommandRoute(name string) (Route, bool) {
+	route, ok := r.commandMap[name]
+	return route, ok
+}
+
+func (r *Registry) GetComponentRoute(receiver string) (Route, bool) {
+	route, ok := r.componentMap[receiver]
+	return route, ok
+}
+
+func (r *Registry) GetModalRoute(receiver string) (Route, bool) {
+	route, ok := r.modalMap[receiver]
+	return route, ok
+}
+
+func (r *Registry) GetAutocompleteRoute(name string) (Route, bool) {
+	route, ok := r.autocompleteMap[name]
+	return route, ok
+}
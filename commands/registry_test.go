@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/lilacse/kagura/dataservices"
+)
+
+// Checks that every registered schema has a handler and every handler has a schema, including the autocomplete
+// handlers of options that autocomplete.
+func TestCommandsHaveSchemasAndHandlers(t *testing.T) {
+	datasvcs, err := dataservices.NewProvider(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmds := GetCommands(nil, nil, datasvcs)
+
+	_, err = NewRegistry(cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range cmds {
+		name := c.Schema.Name
+		if name == "" {
+			t.Errorf("a command has a handler but no schema")
+			continue
+		}
+
+		if c.Handler == nil {
+			t.Errorf("command %s has a schema but no handler", name)
+		}
+
+		for receiver, h := range c.Components {
+			if h == nil {
+				t.Errorf("component receiver %s of command %s has no handler", receiver, name)
+			}
+		}
+
+		for receiver, h := range c.Modals {
+			if h == nil {
+				t.Errorf("modal receiver %s of command %s has no handler", receiver, name)
+			}
+		}
+
+		hasAutocomplete := false
+		for _, opt := range c.Schema.Options {
+			if o, ok := opt.(*discord.StringOption); ok && o.Autocomplete {
+				hasAutocomplete = true
+			}
+		}
+
+		if hasAutocomplete && c.Autocomplete == nil {
+			t.Errorf("command %s has an autocomplete option but no autocomplete handler", name)
+		}
+
+		if !hasAutocomplete && c.Autocomplete != nil {
+			t.Errorf("command %s has an autocomplete handler but no autocomplete option", name)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/diamondburned/arikawa/v3/gateway"
 	"github.com/diamondburned/arikawa/v3/state"
 	"github.com/diamondburned/arikawa/v3/utils/json/option"
+	"github.com/diamondburned/arikawa/v3/utils/sendpart"
 	"github.com/lilacse/kagura/embedbuilder"
 )
 
@@ -42,6 +43,10 @@ func sendReplyWithComponents(st *state.State, em discord.Embed, cc []discord.Top
 }
 
 func sendInteractionResponse(st *state.State, em discord.Embed, cc []discord.TopLevelComponent, e *gateway.InteractionCreateEvent) {
+	sendInteractionResponseWithFiles(st, em, cc, []sendpart.File{}, e)
+}
+
+func sendInteractionResponseWithFiles(st *state.State, em discord.Embed, cc []discord.TopLevelComponent, files []sendpart.File, e *gateway.InteractionCreateEvent) {
 	ccs := discord.TopLevelComponents{}
 	for _, c := range cc {
 		ccs = append(ccs, c)
@@ -57,6 +62,7 @@ func sendInteractionResponse(st *state.State, em discord.Embed, cc []discord.Top
 			AllowedMentions: &api.AllowedMentions{
 				RepliedUser: option.False,
 			},
+			Files: files,
 		},
 	}
 
@@ -31,18 +31,7 @@ func NewSaveHandler(store *store.Store, db *database.Service, songdata *songdata
 }
 
 func (h *saveHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
-	var data *discord.CommandInteraction
-
-	switch e.Data.(type) {
-	case *discord.CommandInteraction:
-		data = e.Data.(*discord.CommandInteraction)
-	default:
-		return false
-	}
-
-	if data.Name != "save" {
-		return false
-	}
+	data := e.Data.(*discord.CommandInteraction)
 
 	st := h.store.Bot.State()
 
@@ -68,9 +57,44 @@ func (h *saveHandler) HandleSlashCommand(ctx context.Context, e *gateway.Interac
 		return true
 	}
 
-	newId, ts := saveScore(ctx, h, int64(e.Sender().ID), chart.Id, score, e)
+	if msg, ok := getUnachievableScoreMessage(chart, score); ok {
+		sendCommandErrorReply(st, msg, e)
+		return true
+	}
+
+	userId := int64(e.Sender().ID)
+	now := time.Now()
+	playedAt := now
+
+	playedAtStr := data.Options.Find("played_at").String()
+	if playedAtStr != "" {
+		loc, err := getUserLocation(ctx, userId)
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		t, errStr, ok := parsePlayedAt(playedAtStr, loc, now)
+		if !ok {
+			sendCommandErrorReply(st, errStr, e)
+			return true
+		}
+
+		errStr, ok = validatePlayedAt(chart, t, now)
+		if !ok {
+			sendCommandErrorReply(st, errStr, e)
+			return true
+		}
+
+		playedAt = t
+	}
+
+	saved, ok := saveScore(ctx, h, userId, chart.Id, score, playedAt, e)
+	if !ok {
+		return true
+	}
 
-	res := createSaveResponseEmbed(song, chart, score, newId, ts)
+	res := createSaveResponseEmbed(song, chart, score, saved)
 	components := createSaveButtons(int64(e.Sender().ID), chart.Id)
 	sendInteractionResponse(st, res, components, e)
 
@@ -88,26 +112,26 @@ func createSaveButtons(userId int64, chartId int) []discord.TopLevelComponent {
 	}
 }
 
-func saveScore(ctx context.Context, h *saveHandler, userId int64, chartId int, score int, e *gateway.InteractionCreateEvent) (int64, time.Time) {
-	st := h.store.Bot.State()
+type saveResult struct {
+	Id        int64
+	Timestamp time.Time
+	// the best score of the chart before this score was saved, with an Id of 0 if there was none.
+	PrevBest database.ScoreRecord
+	PrevB30  []database.ScoreRecordRating
+	B30      []database.ScoreRecordRating
+}
 
-	sess, err := h.db.NewSession(ctx)
-	if err != nil {
-		logAndSendCommandError(ctx, st, err, e)
-		return 0, time.Time{}
-	}
+// Saves a score played at the given time. The score is tagged with the open practice session of the user, unless it
+// was played before the session started.
+func saveScore(ctx context.Context, h *saveHandler, userId int64, chartId int, score int, playedAt time.Time, e *gateway.InteractionCreateEvent) (saveResult, bool) {
+	st := h.store.Bot.State()
 
-	defer func() {
-		err := sess.Conn.Close()
-		if err != nil {
-			logAndSendCommandError(ctx, st, err, e)
-		}
-	}()
+	sess := database.GetSession(ctx)
 
 	tx, err := sess.Conn.BeginTx(ctx, nil)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
-		return 0, time.Time{}
+		return saveResult{}, false
 	}
 
 	isCommit := false
@@ -123,55 +147,66 @@ func saveScore(ctx context.Context, h *saveHandler, userId int64, chartId int, s
 
 	scoresRepo := sess.GetScoresRepo()
 
-	userScores, err := scoresRepo.GetByUserAndChart(ctx, int64(e.Sender().ID), chartId)
+	practiceSessions, err := sess.GetPracticeSessionsRepo().GetOpenByUser(ctx, userId)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
-		return 0, time.Time{}
+		return saveResult{}, false
 	}
 
-	// we store up to the 30th recently saved score and the user's best score.
+	practiceSessionId := int64(0)
+	if len(practiceSessions) > 0 && playedAt.UnixMilli() >= practiceSessions[0].StartedAt {
+		practiceSessionId = practiceSessions[0].Id
+	}
 
-	scoreCount := len(userScores)
-	if scoreCount >= 30 {
-		best := userScores[0]
-		for _, s := range userScores {
-			if s.Score > best.Score {
-				best = s
-			}
-		}
+	prevBest, err := scoresRepo.GetBestScoreByUserAndChart(ctx, userId, chartId)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return saveResult{}, false
+	}
 
-		slices.SortFunc(userScores, func(a, b database.ScoreRecord) int {
-			return int(a.Timestamp - b.Timestamp)
-		})
+	prevB30, err := scoresRepo.GetBestScoresByUserWithOffset(ctx, userId, 0, 30)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return saveResult{}, false
+	}
 
-		if best.Timestamp != userScores[0].Timestamp {
-			scoresRepo.Delete(ctx, userScores[0].Id)
-		} else if scoreCount > 30 {
-			scoresRepo.Delete(ctx, userScores[1].Id)
-		}
+	insertRes, err := scoresRepo.Insert(ctx, userId, chartId, score, playedAt.UnixMilli(), practiceSessionId)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return saveResult{}, false
 	}
 
-	ts := time.Now()
+	newId, _ := insertRes.LastInsertId()
 
-	insertRes, err := scoresRepo.Insert(ctx, int64(e.Sender().ID), chartId, score, ts.UnixMilli())
+	_, err = scoresRepo.EnforceRetention(ctx, userId, chartId, h.db.RetentionPolicy(), time.Now(), newId)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
-		return 0, time.Time{}
+		return saveResult{}, false
 	}
 
-	newId, _ := insertRes.LastInsertId()
+	b30, err := scoresRepo.GetBestScoresByUserWithOffset(ctx, userId, 0, 30)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return saveResult{}, false
+	}
 
 	err = tx.Commit()
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
-		return 0, time.Time{}
+		return saveResult{}, false
 	}
 
 	isCommit = true
-	return newId, ts
+	return saveResult{
+		Id:        newId,
+		Timestamp: playedAt,
+		PrevBest:  prevBest,
+		PrevB30:   prevB30,
+		B30:       b30,
+	}, true
 }
 
-func createSaveResponseEmbed(song songdata.Song, chart songdata.Chart, score int, newId int64, ts time.Time) discord.Embed {
+func createSaveResponseEmbed(song songdata.Song, chart songdata.Chart, score int, res saveResult) discord.Embed {
 	embed := discord.Embed{
 		Title: "Score saved",
 		Fields: []discord.EmbedField{
@@ -195,28 +230,88 @@ func createSaveResponseEmbed(song songdata.Song, chart songdata.Chart, score int
 			},
 			{
 				Name:   "Timestamp",
-				Value:  fmt.Sprintf("<t:%v:R>", ts.Unix()),
+				Value:  fmt.Sprintf("<t:%v:R>", res.Timestamp.Unix()),
 				Inline: true,
 			},
+			{
+				Name:  "Personal best",
+				Value: getPersonalBestDescription(score, res.PrevBest),
+			},
+			{
+				Name:  "b30",
+				Value: getB30ImpactDescription(chart, score, res),
+			},
 		},
 		Footer: &discord.EmbedFooter{
-			Text: fmt.Sprintf("Send `/unsave %v` to delete this score.", newId),
+			Text: fmt.Sprintf("Send `/unsave %v` to delete this score.", res.Id),
 		},
 	}
 
 	return embedbuilder.Info(embed)
 }
 
+func getPersonalBestDescription(score int, prevBest database.ScoreRecord) string {
+	switch {
+	case prevBest.Id == 0:
+		return "**New personal best!** This is your first score on this chart."
+	case score > prevBest.Score:
+		return fmt.Sprintf("**New personal best!** %v ▸ **%v** (+%v)", prevBest.Score, score, score-prevBest.Score)
+	default:
+		return fmt.Sprintf("Your best is %v (%v)", prevBest.Score, score-prevBest.Score)
+	}
+}
+
+func getB30ImpactDescription(chart songdata.Chart, score int, res saveResult) string {
+	// only the best score of a chart is counted in the b30, so a play enters it only as a new personal best.
+	isPb := res.PrevBest.Id == 0 || score > res.PrevBest.Score
+
+	rank := 0
+	if isPb {
+		for i, s := range res.B30 {
+			if s.ChartId == chart.Id {
+				rank = i + 1
+				break
+			}
+		}
+	}
+
+	if rank > 0 {
+		prevAvg := getScoreRatingsAverage(res.PrevB30)
+		avg := getScoreRatingsAverage(res.B30)
+		return fmt.Sprintf("Entered your b30 at **#%v**\nAverage rating: %.4f ▸ **%.4f** (%+.4f)", rank, prevAvg, avg, avg-prevAvg)
+	}
+
+	if !isPb && slices.ContainsFunc(res.B30, func(s database.ScoreRecordRating) bool { return s.ChartId == chart.Id }) {
+		return "Not entered, your best score on this chart is already in your b30."
+	}
+
+	if len(res.B30) == 0 {
+		return "Not entered."
+	}
+
+	last := res.B30[len(res.B30)-1]
+	return fmt.Sprintf("Not entered, %.4f short of your 30th entry (Play Rating %.4f).", last.Rating-chart.GetActualScoreRating(score), last.Rating)
+}
+
+func getScoreRatingsAverage(ratings []database.ScoreRecordRating) float64 {
+	if len(ratings) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, r := range ratings {
+		sum += r.Rating
+	}
+
+	return sum / float64(len(ratings))
+}
+
 func (h *saveHandler) HandleSaveAnother(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
 	st := h.store.Bot.State()
 
 	val := e.Data.(*discord.ButtonInteraction).CustomID
 
 	params := strings.Split(string(val), ",")
-	receiver := params[1]
-	if receiver != "save" {
-		return false
-	}
 
 	userId, _ := strconv.ParseInt(params[0], 10, 64)
 	chartId, _ := strconv.Atoi(params[2])
@@ -245,10 +340,6 @@ func (h *saveHandler) HandleSaveAnotherModalSubmit(ctx context.Context, e *gatew
 	val := in.CustomID
 
 	params := strings.Split(string(val), ",")
-	receiver := params[1]
-	if receiver != "save_another_score" {
-		return false
-	}
 
 	userId, _ := strconv.ParseInt(params[0], 10, 64)
 	chartId, _ := strconv.Atoi(params[2])
@@ -265,9 +356,17 @@ func (h *saveHandler) HandleSaveAnotherModalSubmit(ctx context.Context, e *gatew
 		return true
 	}
 
-	newId, ts := saveScore(ctx, h, userId, chartId, score, e)
+	if msg, ok := getUnachievableScoreMessage(chart, score); ok {
+		sendInteractionResponse(st, embedbuilder.UserError(msg), []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	saved, ok := saveScore(ctx, h, userId, chartId, score, time.Now(), e)
+	if !ok {
+		return true
+	}
 
-	res := createSaveResponseEmbed(song, chart, score, newId, ts)
+	res := createSaveResponseEmbed(song, chart, score, saved)
 	components := createSaveButtons(int64(e.Sender().ID), chart.Id)
 	sendInteractionResponse(st, res, components, e)
 
@@ -0,0 +1,370 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/google/uuid"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/logger"
+	"github.com/lilacse/kagura/store"
+)
+
+type saveBatchHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewSaveBatchHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *saveBatchHandler {
+	return &saveBatchHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+// each score takes up to about 170 characters in the preview and the result, with the longest song titles and a
+// second line for the played at time or score ID. 20 scores keep the embed description under Discord's limit of 4096
+// characters.
+const maxBatchLines = 20
+
+func (h *saveBatchHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	ccs := []discord.TopLevelComponent{
+		&discord.LabelComponent{
+			Label:       "Scores",
+			Description: fmt.Sprintf("One score per line as: song, diff, score[, played at] (up to %v lines)", maxBatchLines),
+			Component: &discord.TextInputComponent{
+				CustomID:     discord.ComponentID("save_batch_input"),
+				Style:        discord.TextInputParagraphStyle,
+				LengthLimits: [2]int{1, 4000},
+				Required:     true,
+				Placeholder:  "sheriruth, ftr, 9912345\ngrievous lady, byd, 9801234, 2h ago",
+			},
+		},
+	}
+
+	sendModalResponse(st, fmt.Sprintf("%v,save_batch", e.Sender().ID), "Save scores", ccs, e)
+
+	return true
+}
+
+func (h *saveBatchHandler) HandleSaveBatchModalSubmit(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	in := e.Data.(*discord.ModalInteraction)
+
+	userId := int64(e.Sender().ID)
+
+	// workaround: .Find() does not seem to work for components that are nested.
+	input := in.Components[0].(*discord.LabelComponent).Component.(*discord.TextInputComponent).Value
+
+	lines := make([]string, 0)
+	for _, l := range strings.Split(input, "\n") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+
+	if len(lines) > maxBatchLines {
+		sendInteractionResponse(st, embedbuilder.UserError(fmt.Sprintf("Too many scores, up to %v can be saved at once!", maxBatchLines)), []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	loc, err := getUserLocation(ctx, userId)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	now := time.Now()
+	scores := make([]store.PendingScore, 0, len(lines))
+	previewBuilder := strings.Builder{}
+	errorsBuilder := strings.Builder{}
+
+	for i, l := range lines {
+		song, chart, s, errStr, ok := parseBatchLine(h.songdata, l, loc, now)
+		if !ok {
+			fmt.Fprintf(&errorsBuilder, "Line %v: %s\n", i+1, errStr)
+			continue
+		}
+
+		scores = append(scores, s)
+		fmt.Fprintf(&previewBuilder, "%v. %s ▸ %s Lv%s - %v (Play Rating %s)\n", len(scores), song.EscapedAltTitle(), strings.ToUpper(chart.Diff), chart.Level, s.Score, chart.GetScoreRatingString(s.Score))
+		if !s.PlayedAt.IsZero() {
+			fmt.Fprintf(&previewBuilder, "  -# Played <t:%v:f>\n", s.PlayedAt.Unix())
+		}
+	}
+
+	if len(scores) == 0 {
+		sendInteractionResponse(st, embedbuilder.UserError(fmt.Sprintf("None of the scores could be read!\n%s", errorsBuilder.String())), []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	batchId := uuid.NewString()
+	h.store.Batches.Add(batchId, store.PendingBatch{
+		UserId:    userId,
+		Scores:    scores,
+		CreatedAt: time.Now(),
+	})
+
+	embed := discord.Embed{
+		Title:       fmt.Sprintf("Save %v scores?", len(scores)),
+		Description: previewBuilder.String(),
+		Footer: &discord.EmbedFooter{
+			Text: "Nothing is saved until you confirm. This preview expires in 15 minutes.",
+		},
+	}
+
+	if errorsBuilder.Len() > 0 {
+		skipped := errorsBuilder.String()
+		// field values are limited to 1024 characters.
+		if len(skipped) > 1000 {
+			skipped = skipped[:strings.LastIndex(skipped[:1000], "\n")+1] + "..."
+		}
+
+		embed.Fields = []discord.EmbedField{
+			{
+				Name:  "Skipped lines",
+				Value: skipped,
+			},
+		}
+	}
+
+	sendInteractionResponse(st, embedbuilder.Info(embed), createSaveBatchButtons(userId, batchId), e)
+
+	return true
+}
+
+func (h *saveBatchHandler) HandleSaveBatchConfirm(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	val := e.Data.(*discord.ButtonInteraction).CustomID
+
+	params := strings.Split(string(val), ",")
+
+	action := params[2]
+	batchId := params[3]
+
+	batch, ok := h.store.Batches.Take(batchId)
+	if !ok {
+		updateSaveBatchMessage(st, embedbuilder.UserError("This batch has expired or was already handled, please send `/save-batch` again."), e)
+		return true
+	}
+
+	if action != "confirm" {
+		embed := discord.Embed{
+			Title:       "Batch cancelled",
+			Description: "No scores were saved.",
+		}
+		updateSaveBatchMessage(st, embedbuilder.Info(embed), e)
+		return true
+	}
+
+	ids, err := saveBatch(ctx, h, batch)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	savedBuilder := strings.Builder{}
+	for i, s := range batch.Scores {
+		chart, song, _ := h.songdata.GetChartById(s.ChartId)
+		fmt.Fprintf(&savedBuilder, "%v. %s ▸ %s Lv%s - %v\n  -# Score ID: %v\n", i+1, song.EscapedAltTitle(), strings.ToUpper(chart.Diff), chart.Level, s.Score, ids[i])
+	}
+
+	embed := discord.Embed{
+		Title:       fmt.Sprintf("%v scores saved", len(ids)),
+		Description: savedBuilder.String(),
+	}
+
+	updateSaveBatchMessage(st, embedbuilder.Info(embed), e)
+
+	return true
+}
+
+// Inserts every score of the batch in a single transaction, so either all or none of them are saved.
+func saveBatch(ctx context.Context, h *saveBatchHandler, batch store.PendingBatch) ([]int64, error) {
+	sess := database.GetSession(ctx)
+
+	tx, err := sess.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	isCommit := false
+
+	defer func() {
+		if !isCommit {
+			err := tx.Rollback()
+			if err != nil {
+				logger.Error(ctx, err.Error())
+			}
+		}
+	}()
+
+	scoresRepo := sess.GetScoresRepo()
+
+	practiceSessions, err := sess.GetPracticeSessionsRepo().GetOpenByUser(ctx, batch.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ids := make([]int64, 0, len(batch.Scores))
+	// the scores inserted for each chart, which are exempted from the retention policy even if they are backdated.
+	insertedIds := make(map[int][]int64)
+
+	for _, s := range batch.Scores {
+		playedAt := s.PlayedAt
+		if playedAt.IsZero() {
+			playedAt = now
+		}
+
+		practiceSessionId := int64(0)
+		if len(practiceSessions) > 0 && playedAt.UnixMilli() >= practiceSessions[0].StartedAt {
+			practiceSessionId = practiceSessions[0].Id
+		}
+
+		insertRes, err := scoresRepo.Insert(ctx, batch.UserId, s.ChartId, s.Score, playedAt.UnixMilli(), practiceSessionId)
+		if err != nil {
+			return nil, err
+		}
+
+		newId, _ := insertRes.LastInsertId()
+		ids = append(ids, newId)
+		insertedIds[s.ChartId] = append(insertedIds[s.ChartId], newId)
+	}
+
+	for chartId, keepIds := range insertedIds {
+		_, err := scoresRepo.EnforceRetention(ctx, batch.UserId, chartId, h.db.RetentionPolicy(), now, keepIds...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	isCommit = true
+	return ids, nil
+}
+
+// Parses a line in the format of "song, diff, score" with an optional trailing played at time in the given location.
+// The line is split from the right, as song titles may contain commas.
+func parseBatchLine(sd *songdata.Service, line string, loc *time.Location, now time.Time) (songdata.Song, songdata.Chart, store.PendingScore, string, bool) {
+	parts := strings.Split(line, ",")
+	if len(parts) < 3 {
+		return songdata.Song{}, songdata.Chart{}, store.PendingScore{}, fmt.Sprintf("`%s` is not in the format of song, diff, score[, played at]", strings.TrimSpace(line)), false
+	}
+
+	// a played at time never parses as a score, so a trailing field that parses as a time is taken as the played at
+	// time rather than part of the song title.
+	playedAt := time.Time{}
+	if len(parts) >= 4 {
+		t, errStr, ok := parsePlayedAt(parts[len(parts)-1], loc, now)
+		if ok {
+			playedAt = t
+			parts = parts[:len(parts)-1]
+		} else if _, isDiff := parseDiffKey(strings.TrimSpace(parts[len(parts)-3])); isDiff {
+			if _, _, isScore := parseFullScore(strings.TrimSpace(parts[len(parts)-1])); !isScore {
+				return songdata.Song{}, songdata.Chart{}, store.PendingScore{}, errStr, false
+			}
+		}
+	}
+
+	query := strings.TrimSpace(strings.Join(parts[:len(parts)-2], ","))
+	diffStr := strings.TrimSpace(parts[len(parts)-2])
+	scoreStr := strings.TrimSpace(parts[len(parts)-1])
+
+	matched := sd.Search(query, 1)
+	if len(matched) == 0 {
+		return songdata.Song{}, songdata.Chart{}, store.PendingScore{}, fmt.Sprintf("No matching song found for query `%s`", query), false
+	}
+
+	song := matched[0]
+
+	diffKey, ok := parseDiffKey(diffStr)
+	if !ok {
+		return songdata.Song{}, songdata.Chart{}, store.PendingScore{}, fmt.Sprintf("Invalid difficulty `%s`", diffStr), false
+	}
+
+	chart, ok := song.GetChart(diffKey)
+	if !ok {
+		return songdata.Song{}, songdata.Chart{}, store.PendingScore{}, fmt.Sprintf("Difficulty %s does not exist for the song %s", strings.ToUpper(diffKey), song.EscapedAltTitle()), false
+	}
+
+	score, errStr, ok := parseFullScore(scoreStr)
+	if !ok {
+		return songdata.Song{}, songdata.Chart{}, store.PendingScore{}, errStr, false
+	}
+
+	if msg, ok := getUnachievableScoreMessage(chart, score); ok {
+		return songdata.Song{}, songdata.Chart{}, store.PendingScore{}, msg, false
+	}
+
+	if !playedAt.IsZero() {
+		errStr, ok := validatePlayedAt(chart, playedAt, now)
+		if !ok {
+			return songdata.Song{}, songdata.Chart{}, store.PendingScore{}, errStr, false
+		}
+	}
+
+	return song, chart, store.PendingScore{ChartId: chart.Id, Score: score, PlayedAt: playedAt}, "", true
+}
+
+// Parses a difficulty given either as its key (e.g. ftr) or its full name (e.g. Future).
+func parseDiffKey(s string) (string, bool) {
+	s = strings.ToLower(s)
+
+	for _, key := range []string{"pst", "prs", "ftr", "etr", "byd"} {
+		if s == key || s == strings.ToLower(getFullDiffName(key)) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func createSaveBatchButtons(userId int64, batchId string) []discord.TopLevelComponent {
+	return []discord.TopLevelComponent{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				Label:    "Confirm",
+				Style:    discord.SuccessButtonStyle(),
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,save_batch,confirm,%s", userId, batchId)),
+			},
+			&discord.ButtonComponent{
+				Label:    "Cancel",
+				Style:    discord.SecondaryButtonStyle(),
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,save_batch,cancel,%s", userId, batchId)),
+			},
+		},
+	}
+}
+
+func updateSaveBatchMessage(st *state.State, em discord.Embed, e *gateway.InteractionCreateEvent) {
+	components := []discord.TopLevelComponent{}
+
+	resp := api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Embeds:     &[]discord.Embed{em},
+			Components: (*discord.TopLevelComponents)(&components),
+		},
+	}
+
+	st.RespondInteraction(e.ID, e.Token, resp)
+}
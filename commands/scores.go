@@ -1,17 +1,23 @@
 package commands
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/diamondburned/arikawa/v3/api"
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/utils/sendpart"
 	"github.com/lilacse/kagura/database"
 	"github.com/lilacse/kagura/dataservices/songdata"
 	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/imagebuilder"
 	"github.com/lilacse/kagura/store"
 )
 
@@ -30,18 +36,7 @@ func NewScoresHandler(store *store.Store, db *database.Service, songdata *songda
 }
 
 func (h *scoresHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
-	var data *discord.CommandInteraction
-
-	switch e.Data.(type) {
-	case *discord.CommandInteraction:
-		data = e.Data.(*discord.CommandInteraction)
-	default:
-		return false
-	}
-
-	if data.Name != "scores" {
-		return false
-	}
+	data := e.Data.(*discord.CommandInteraction)
 
 	st := h.store.Bot.State()
 
@@ -61,48 +56,93 @@ func (h *scoresHandler) HandleSlashCommand(ctx context.Context, e *gateway.Inter
 		return true
 	}
 
-	sess, err := h.db.NewSession(ctx)
+	sess := database.GetSession(ctx)
+
+	scoresRepo := sess.GetScoresRepo()
+
+	owner := getTargetUser(data, e)
+	ownerId := int64(owner.ID)
+	viewerId := int64(e.Sender().ID)
+
+	allowed, err := canViewScores(ctx, st, sess.GetUserSettingsRepo(), ownerId, viewerId, e.GuildID)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
 	}
 
-	defer func() {
-		err := sess.Conn.Close()
-		if err != nil {
-			logAndSendCommandError(ctx, st, err, e)
+	if !allowed {
+		sendCommandErrorReply(st, fmt.Sprintf("The scores of %s are not visible to you!", owner.Mention()), e)
+		return true
+	}
+
+	count, err := scoresRepo.GetScoreCountByUserAndChart(ctx, ownerId, chart.Id)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	if count == 0 {
+		if ownerId == viewerId {
+			sendCommandErrorReply(st, "You don't have any scores saved for this chart!", e)
+		} else {
+			sendCommandErrorReply(st, fmt.Sprintf("%s doesn't have any scores saved for this chart!", owner.Mention()), e)
 		}
-	}()
+		return true
+	}
 
-	scoresRepo := sess.GetScoresRepo()
+	bestScore, err := scoresRepo.GetBestScoreByUserAndChart(ctx, ownerId, chart.Id)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
 
-	count, err := scoresRepo.GetScoreCountByUserAndChart(ctx, int64(e.Sender().ID), chart.Id)
+	recentScores, err := scoresRepo.GetByUserAndChartWithOffset(ctx, ownerId, chart.Id, 0, 5)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
 	}
 
-	if count == 0 {
-		sendCommandErrorReply(st, "You don't have any scores saved for this chart!", e)
+	embed := describeScoresOwner(createScoresEmbed(song, chart, bestScore, recentScores, 0), ownerId, viewerId)
+	components := createScoresPageButtons(viewerId, ownerId, chart.Id, count, 0)
+
+	showEdits, _ := data.Options.Find("edits").BoolValue()
+	if showEdits {
+		edits, err := sess.GetScoreEditsRepo().GetByUserAndChart(ctx, ownerId, chart.Id, 5)
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		embed.Fields = append(embed.Fields, createScoreEditsField(edits))
+	}
+
+	graph, _ := data.Options.Find("graph").BoolValue()
+	if !graph {
+		sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
 		return true
 	}
 
-	bestScore, err := scoresRepo.GetBestScoreByUserAndChart(ctx, int64(e.Sender().ID), chart.Id)
+	allScores, err := scoresRepo.GetByUserAndChart(ctx, ownerId, chart.Id)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
 	}
 
-	recentScores, err := scoresRepo.GetByUserAndChartWithOffset(ctx, int64(e.Sender().ID), chart.Id, 0, 5)
+	img, err := createScoresGraph(song, chart, allScores)
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
 	}
 
-	embed := createScoresEmbed(song, chart, bestScore, recentScores, 0)
-	components := createScoresPageButtons(int64(e.Sender().ID), chart.Id, count, 0)
+	embed.Image = &discord.EmbedImage{
+		URL: "attachment://scores.png",
+	}
 
-	sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
+	files := []sendpart.File{
+		{Name: "scores.png", Reader: bytes.NewReader(img)},
+	}
+
+	sendInteractionResponseWithFiles(st, embedbuilder.Info(embed), components, files, e)
 
 	return true
 }
@@ -113,54 +153,59 @@ func (h *scoresHandler) HandleScorePageSelect(ctx context.Context, e *gateway.In
 	val := e.Data.(*discord.ButtonInteraction).CustomID
 
 	params := strings.Split(string(val), ",")
-	receiver := params[1]
-	if receiver != "scores" {
-		return false
-	}
 
-	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	viewerId, _ := strconv.ParseInt(params[0], 10, 64)
+
+	// buttons sent before other users' scores could be viewed do not have the owner, which is always the viewer.
+	ownerId := viewerId
 	chartId, _ := strconv.Atoi(params[2])
 	offset, _ := strconv.Atoi(params[3])
+	if len(params) > 4 {
+		ownerId, _ = strconv.ParseInt(params[2], 10, 64)
+		chartId, _ = strconv.Atoi(params[3])
+		offset, _ = strconv.Atoi(params[4])
+	}
 
 	pageIdx := offset / 5
 
 	chart, song, _ := h.songdata.GetChartById(chartId)
 
-	sess, err := h.db.NewSession(ctx)
+	sess := database.GetSession(ctx)
+
+	scoresRepo := sess.GetScoresRepo()
+
+	// the owner might have changed their privacy setting since the message was sent.
+	allowed, err := canViewScores(ctx, st, sess.GetUserSettingsRepo(), ownerId, viewerId, e.GuildID)
 	if err != nil {
 		logAndSendInteractionError(ctx, st, err, e)
 		return true
 	}
 
-	defer func() {
-		err := sess.Conn.Close()
-		if err != nil {
-			logAndSendInteractionError(ctx, st, err, e)
-		}
-	}()
-
-	scoresRepo := sess.GetScoresRepo()
+	if !allowed {
+		sendInteractionReply(st, embedbuilder.UserError(fmt.Sprintf("The scores of <@%v> are no longer visible to you!", ownerId)), e)
+		return true
+	}
 
-	count, err := scoresRepo.GetScoreCountByUserAndChart(ctx, userId, chart.Id)
+	count, err := scoresRepo.GetScoreCountByUserAndChart(ctx, ownerId, chart.Id)
 	if err != nil {
 		logAndSendInteractionError(ctx, st, err, e)
 		return true
 	}
 
-	bestScore, err := scoresRepo.GetBestScoreByUserAndChart(ctx, userId, chartId)
+	bestScore, err := scoresRepo.GetBestScoreByUserAndChart(ctx, ownerId, chartId)
 	if err != nil {
 		logAndSendInteractionError(ctx, st, err, e)
 		return true
 	}
 
-	recentScores, err := scoresRepo.GetByUserAndChartWithOffset(ctx, userId, chartId, offset, 5)
+	recentScores, err := scoresRepo.GetByUserAndChartWithOffset(ctx, ownerId, chartId, offset, 5)
 	if err != nil {
 		logAndSendInteractionError(ctx, st, err, e)
 		return true
 	}
 
-	embed := createScoresEmbed(song, chart, bestScore, recentScores, offset)
-	components := createScoresPageButtons(userId, chart.Id, count, pageIdx)
+	embed := describeScoresOwner(createScoresEmbed(song, chart, bestScore, recentScores, offset), ownerId, viewerId)
+	components := createScoresPageButtons(viewerId, ownerId, chart.Id, count, pageIdx)
 
 	resp := api.InteractionResponse{
 		Type: api.UpdateMessage,
@@ -199,19 +244,65 @@ func createScoresEmbed(song songdata.Song, chart songdata.Chart, best database.S
 	return embed
 }
 
-func createScoresPageButtons(userId int64, chartId int, count int, pageIdx int) []discord.TopLevelComponent {
+func createScoreEditsField(edits []database.ScoreEditRecord) discord.EmbedField {
+	if len(edits) == 0 {
+		return discord.EmbedField{
+			Name:  "Edit history",
+			Value: "No scores for this chart were edited.",
+		}
+	}
+
+	editsBuilder := strings.Builder{}
+
+	for _, ed := range edits {
+		fmt.Fprintf(&editsBuilder, "Score ID %v (edited <t:%v:R>)\n", ed.ScoreId, ed.EditedAt/1000)
+		if ed.OldScore != ed.NewScore {
+			fmt.Fprintf(&editsBuilder, "  %v ▸ %v\n", ed.OldScore, ed.NewScore)
+		}
+		if ed.OldTimestamp != ed.NewTimestamp {
+			fmt.Fprintf(&editsBuilder, "  <t:%v:f> ▸ <t:%v:f>\n", ed.OldTimestamp/1000, ed.NewTimestamp/1000)
+		}
+	}
+
+	return discord.EmbedField{
+		Name:  "Edit history",
+		Value: editsBuilder.String(),
+	}
+}
+
+func createScoresGraph(song songdata.Song, chart songdata.Chart, scores []database.ScoreRecord) ([]byte, error) {
+	slices.SortFunc(scores, func(a, b database.ScoreRecord) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+
+	points := make([]imagebuilder.Point, 0, len(scores))
+	for _, s := range scores {
+		points = append(points, imagebuilder.Point{Time: time.UnixMilli(s.Timestamp), Value: float64(s.Score)})
+	}
+
+	return imagebuilder.RenderLineChart(imagebuilder.LineChart{
+		Title: fmt.Sprintf("%s ▸ %s Lv%s", song.AltTitle, chart.GetDiffDisplayName(), chart.Level),
+		Series: []imagebuilder.Series{
+			{Name: "Score", Color: imagebuilder.PrimaryColor, Points: points},
+		},
+		ValueFormat: "%.0f",
+	})
+}
+
+// The buttons are only usable by the viewer, while the scores shown are always of the owner.
+func createScoresPageButtons(viewerId int64, ownerId int64, chartId int, count int, pageIdx int) []discord.TopLevelComponent {
 	prevOffset := (pageIdx - 1) * 5
 	nextOffset := (pageIdx + 1) * 5
 
 	return []discord.TopLevelComponent{
 		&discord.ActionRowComponent{
 			&discord.ButtonComponent{
-				CustomID: discord.ComponentID(fmt.Sprintf("%v,scores,%v,%v", userId, chartId, prevOffset)),
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,scores,%v,%v,%v", viewerId, ownerId, chartId, prevOffset)),
 				Label:    "<",
 				Disabled: prevOffset < 0,
 			},
 			&discord.ButtonComponent{
-				CustomID: discord.ComponentID(fmt.Sprintf("%v,scores,%v,%v", userId, chartId, nextOffset)),
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,scores,%v,%v,%v", viewerId, ownerId, chartId, nextOffset)),
 				Label:    ">",
 				Disabled: nextOffset >= count,
 			},
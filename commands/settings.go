@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type settingsHandler struct {
+	store *store.Store
+	db    *database.Service
+}
+
+func NewSettingsHandler(store *store.Store, db *database.Service) *settingsHandler {
+	return &settingsHandler{
+		store: store,
+		db:    db,
+	}
+}
+
+var privacyLevels = map[string]database.PrivacyLevel{
+	"private": database.PrivacyPrivate,
+	"guild":   database.PrivacyGuild,
+	"public":  database.PrivacyPublic,
+}
+
+func (h *settingsHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	data := e.Data.(*discord.CommandInteraction)
+
+	st := h.store.Bot.State()
+
+	sess := database.GetSession(ctx)
+
+	settingsRepo := sess.GetUserSettingsRepo()
+	userId := int64(e.Sender().ID)
+
+	privacyKey := data.Options.Find("privacy").String()
+	if privacyKey != "" {
+		_, err := settingsRepo.SetPrivacy(ctx, userId, privacyLevels[privacyKey])
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+	}
+
+	timezone := data.Options.Find("timezone").String()
+	if timezone != "" {
+		// "Local" is accepted by time.LoadLocation, but refers to the timezone of the host.
+		_, err := time.LoadLocation(timezone)
+		if err != nil || timezone == "Local" {
+			sendCommandErrorReply(st, fmt.Sprintf("Unknown timezone `%s`, expecting a name like `Asia/Tokyo` or `UTC`!", timezone), e)
+			return true
+		}
+
+		_, err = settingsRepo.SetTimezone(ctx, userId, timezone)
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+	}
+
+	rankingsRepo := sess.GetGuildRankingsRepo()
+	guildId := int64(e.GuildID)
+
+	rankingsOpt := data.Options.Find("rankings")
+	if rankingsOpt.Name != "" {
+		if !e.GuildID.IsValid() {
+			sendCommandErrorReply(st, "Leaderboard rankings can only be changed in a server!", e)
+			return true
+		}
+
+		var err error
+		join, _ := rankingsOpt.BoolValue()
+		if join {
+			_, err = rankingsRepo.Join(ctx, guildId, userId)
+		} else {
+			_, err = rankingsRepo.Leave(ctx, guildId, userId)
+		}
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+	}
+
+	settings, err := settingsRepo.Get(ctx, userId)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	embed := discord.Embed{
+		Title: "Settings",
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Score visibility",
+				Value: getPrivacyDescription(settings.Privacy),
+			},
+			{
+				Name:  "Timezone",
+				Value: fmt.Sprintf("**%s** - used when saving scores with a `played_at` date and time.", settings.Timezone),
+			},
+		},
+	}
+
+	if e.GuildID.IsValid() {
+		joined, err := rankingsRepo.IsJoined(ctx, guildId, userId)
+		if err != nil {
+			logAndSendCommandError(ctx, st, err, e)
+			return true
+		}
+
+		embed.Fields = append(embed.Fields, discord.EmbedField{
+			Name:  "Server leaderboards",
+			Value: getRankingsDescription(joined),
+		})
+	}
+
+	sendCommandReply(st, embedbuilder.Info(embed), e)
+
+	return true
+}
+
+func getPrivacyDescription(privacy database.PrivacyLevel) string {
+	switch privacy {
+	case database.PrivacyPublic:
+		return "**Public** - everyone can view your saved scores with `/b30` and `/scores`."
+	case database.PrivacyGuild:
+		return "**Guild members** - members of servers you are in can view your saved scores with `/b30` and `/scores`."
+	default:
+		return "**Private** - only you can view your saved scores."
+	}
+}
+
+func getRankingsDescription(joined bool) string {
+	if joined {
+		return "**Ranked** - your best scores are shown in the `/leaderboard` of this server, regardless of your score visibility."
+	}
+
+	return "**Not ranked** - your scores are not shown in the `/leaderboard` of this server."
+}
+
+// Returns the location of the timezone configured by the user.
+func getUserLocation(ctx context.Context, userId int64) (*time.Location, error) {
+	settings, err := database.GetSession(ctx).GetUserSettingsRepo().Get(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return time.LoadLocation(settings.Timezone)
+}
@@ -7,10 +7,14 @@ import (
 	"github.com/diamondburned/arikawa/v3/api/cmdroute"
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices"
 	"github.com/lilacse/kagura/logger"
+	"github.com/lilacse/kagura/store"
 )
 
-func RegisterCommands(ctx context.Context, st *state.State) {
+// Returns the definitions of every command, with their handlers built from the given services.
+func GetCommands(store *store.Store, db *database.Service, datasvcs *dataservices.Provider) []Command {
 	diffChoices := []discord.StringChoice{
 		{Name: "Past", Value: "pst"},
 		{Name: "Present", Value: "prs"},
@@ -19,6 +23,20 @@ func RegisterCommands(ctx context.Context, st *state.State) {
 		{Name: "Eternal", Value: "etr"},
 	}
 
+	playPlusChoices := []discord.IntegerChoice{
+		{Name: "x2", Value: 2},
+		{Name: "x3", Value: 3},
+		{Name: "x4", Value: 4},
+		{Name: "x5", Value: 5},
+		{Name: "x6", Value: 6},
+	}
+
+	fragmentBoostChoices := []discord.NumberChoice{
+		{Name: "x1.1 (100 fragments)", Value: 1.1},
+		{Name: "x1.25 (250 fragments)", Value: 1.25},
+		{Name: "x1.5 (500 fragments)", Value: 1.5},
+	}
+
 	levelChoices := []discord.StringChoice{
 		{Name: "Lv1", Value: "1"},
 		{Name: "Lv2", Value: "2"},
@@ -39,142 +57,475 @@ func RegisterCommands(ctx context.Context, st *state.State) {
 		{Name: "Lv12", Value: "12"},
 	}
 
-	cmds := []api.CreateCommandData{
+	save := NewSaveHandler(store, db, datasvcs.SongData())
+	unsave := NewUnsaveHandler(store, db, datasvcs.SongData())
+	b30 := NewB30Handler(store, db, datasvcs.SongData())
+	scores := NewScoresHandler(store, db, datasvcs.SongData())
+	leaderboard := NewLeaderboardHandler(store, db, datasvcs.SongData())
+	ranking := NewRankingHandler(store, db, datasvcs.SongData())
+	stats := NewStatsHandler(store, db, datasvcs.SongData())
+	progress := NewProgressHandler(store, db, datasvcs.SongData())
+	saveBatch := NewSaveBatchHandler(store, db, datasvcs.SongData())
+	trash := NewTrashHandler(store, db, datasvcs.SongData())
+	edit := NewEditHandler(store, db, datasvcs.SongData())
+
+	return []Command{
 		{
-			Name:        "song",
-			Description: "Queries for a song",
-			Options: []discord.CommandOption{
-				&discord.StringOption{
-					OptionName:  "query",
-					Description: "Search term for the song",
-					Required:    true,
+			Schema: api.CreateCommandData{
+				Name:        "song",
+				Description: "Queries for a song",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "query",
+						Description: "Search term for the song",
+						Required:    true,
+					},
 				},
 			},
+			Handler: NewSongHandler(store, datasvcs.SongData()).HandleSlashCommand,
 		},
 		{
-			Name:        "step",
-			Description: "Calculates the amount of steps a play gives in World Mode",
-			Options: []discord.CommandOption{
-				&discord.NumberOption{
-					OptionName:  "stat",
-					Description: "The STEP stat of the partner",
-					Required:    true,
+			Schema: api.CreateCommandData{
+				Name:        "step",
+				Description: "Calculates the amount of steps a play gives in World Mode",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "song",
+						Description: "Search term for the song",
+						Required:    true,
+					},
+					&discord.StringOption{
+						OptionName:  "diff",
+						Description: "The difficulty of the chart",
+						Required:    true,
+						Choices:     diffChoices,
+					},
+					&discord.NumberOption{
+						OptionName:  "stat",
+						Description: "The STEP stat of the partner",
+						Required:    true,
+					},
+					&discord.IntegerOption{
+						OptionName:  "score",
+						Description: "The score of the play, supports short score format (e.g. 980 instead of 9800000)",
+					},
+					&discord.NumberOption{
+						OptionName:  "target",
+						Description: "The progress to reach, to calculate the minimum score instead of using score",
+					},
+					&discord.NumberOption{
+						OptionName:  "partner_bonus",
+						Description: "The progression bonus of the partner, added to the progress of each play",
+					},
+					&discord.IntegerOption{
+						OptionName:  "play_plus",
+						Description: "The stamina multiplier of Play+",
+						Choices:     playPlusChoices,
+					},
+					&discord.NumberOption{
+						OptionName:  "fragment_boost",
+						Description: "The fragment boost used for each play",
+						Choices:     fragmentBoostChoices,
+					},
+					&discord.NumberOption{
+						OptionName:  "modifier",
+						Description: "The progress multiplier of the map, e.g. for Legacy or Memory Archive maps",
+					},
 				},
-				&discord.StringOption{
-					OptionName:  "song",
-					Description: "Search term for the song",
-					Required:    true,
+			},
+			Handler: NewStepHandler(store, datasvcs.SongData()).HandleSlashCommand,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "save",
+				Description: "Saves a score",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "song",
+						Description: "Search term for the song",
+						Required:    true,
+					},
+					&discord.StringOption{
+						OptionName:  "diff",
+						Description: "The difficulty of the chart",
+						Required:    true,
+						Choices:     diffChoices,
+					},
+					&discord.IntegerOption{
+						OptionName:  "score",
+						Description: "The score of the play",
+						Required:    true,
+					},
+					&discord.StringOption{
+						OptionName:  "played_at",
+						Description: "When the score was played, e.g. 2024-01-02 15:04 in your timezone or 2h ago",
+						Required:    false,
+					},
 				},
-				&discord.StringOption{
-					OptionName:  "diff",
-					Description: "The difficulty of the chart",
-					Required:    true,
-					Choices:     diffChoices,
+			},
+			Handler: save.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"save": save.HandleSaveAnother,
+			},
+			Modals: map[string]InteractionHandler{
+				"save_another_score": save.HandleSaveAnotherModalSubmit,
+			},
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "unsave",
+				Description: "Unsaves a score",
+				Options: []discord.CommandOption{
+					&discord.IntegerOption{
+						OptionName:  "score_id",
+						Description: "The ID of the score to unsave",
+						Required:    true,
+					},
 				},
-				&discord.IntegerOption{
-					OptionName:  "score",
-					Description: "The score of the play, supports short score format (e.g. 980 instead of 9800000)",
-					Required:    true,
+			},
+			Handler: unsave.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"unsave_undo": unsave.HandleUndo,
+			},
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "ptt",
+				Description: "Calculates the rating of a play",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "song",
+						Description: "Search term for the song",
+						Required:    true,
+					},
+					&discord.StringOption{
+						OptionName:  "diff",
+						Description: "The difficulty of the chart",
+						Required:    true,
+						Choices:     diffChoices,
+					},
+					&discord.IntegerOption{
+						OptionName:  "score",
+						Description: "The score of the play, supports short score format (e.g. 980 instead of 9800000)",
+						Required:    true,
+					},
 				},
 			},
+			Handler: NewPttHandler(store, datasvcs.SongData()).HandleSlashCommand,
 		},
 		{
-			Name:        "save",
-			Description: "Saves a score",
-			Options: []discord.CommandOption{
-				&discord.StringOption{
-					OptionName:  "song",
-					Description: "Search term for the song",
-					Required:    true,
+			Schema: api.CreateCommandData{
+				Name:        "random",
+				Description: "Returns a random song",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "level",
+						Description: "The level of the chart",
+						Required:    false,
+						Choices:     levelChoices,
+					},
+					&discord.StringOption{
+						OptionName:  "diff",
+						Description: "The difficulty of the chart",
+						Required:    false,
+						Choices:     diffChoices,
+					},
 				},
-				&discord.StringOption{
-					OptionName:  "diff",
-					Description: "The difficulty of the chart",
-					Required:    true,
-					Choices:     diffChoices,
+			},
+			Handler: NewRandomHandler(store, datasvcs.SongData()).HandleSlashCommand,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "b30",
+				Description: "Shows your top scores alongside a b30 summary",
+				Options: []discord.CommandOption{
+					&discord.UserOption{
+						OptionName:  "user",
+						Description: "Shows the scores of another user instead, if they allow it",
+						Required:    false,
+					},
+					&discord.BooleanOption{
+						OptionName:  "image",
+						Description: "Shows all 30 entries as a single image",
+						Required:    false,
+					},
+					&discord.StringOption{
+						OptionName:  "as_of",
+						Description: "Shows the b30 as of the end of this date (YYYY-MM-DD, in your timezone)",
+						Required:    false,
+					},
 				},
-				&discord.IntegerOption{
-					OptionName:  "score",
-					Description: "The score of the play",
-					Required:    true,
+			},
+			Handler: b30.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"b30": b30.HandleB30PageSelect,
+			},
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "scores",
+				Description: "Shows the scores you saved for a song",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "song",
+						Description: "Search term for the song",
+						Required:    true,
+					},
+					&discord.StringOption{
+						OptionName:  "diff",
+						Description: "The difficulty of the chart",
+						Required:    true,
+						Choices:     diffChoices,
+					},
+					&discord.UserOption{
+						OptionName:  "user",
+						Description: "Shows the scores of another user instead, if they allow it",
+						Required:    false,
+					},
+					&discord.BooleanOption{
+						OptionName:  "graph",
+						Description: "Attaches a graph of your score history for the chart",
+						Required:    false,
+					},
+					&discord.BooleanOption{
+						OptionName:  "edits",
+						Description: "Shows the recent edits made to your scores for the chart",
+						Required:    false,
+					},
 				},
 			},
+			Handler: scores.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"scores": scores.HandleScorePageSelect,
+			},
+			UsesDatabase: true,
 		},
 		{
-			Name:        "unsave",
-			Description: "Unsaves a score",
-			Options: []discord.CommandOption{
-				&discord.IntegerOption{
-					OptionName:  "score_id",
-					Description: "The ID of the score to unsave",
-					Required:    true,
+			Schema: api.CreateCommandData{
+				Name:        "settings",
+				Description: "Shows or changes your settings",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "privacy",
+						Description: "Who can view your saved scores",
+						Required:    false,
+						Choices: []discord.StringChoice{
+							{Name: "Private", Value: "private"},
+							{Name: "Guild members", Value: "guild"},
+							{Name: "Public", Value: "public"},
+						},
+					},
+					&discord.StringOption{
+						OptionName:  "timezone",
+						Description: "Your timezone as an IANA name (e.g. Asia/Tokyo), used for dates you enter",
+						Required:    false,
+					},
+					&discord.BooleanOption{
+						OptionName:  "rankings",
+						Description: "Whether your best scores are ranked in the leaderboards of this server",
+						Required:    false,
+					},
 				},
 			},
+			Handler:      NewSettingsHandler(store, db).HandleSlashCommand,
+			UsesDatabase: true,
 		},
 		{
-			Name:        "ptt",
-			Description: "Calculates the rating of a play",
-			Options: []discord.CommandOption{
-				&discord.StringOption{
-					OptionName:  "song",
-					Description: "Search term for the song",
-					Required:    true,
+			Schema: api.CreateCommandData{
+				Name:        "leaderboard",
+				Description: "Ranks the members of this server by their best score on a chart",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "song",
+						Description: "Search term for the song",
+						Required:    true,
+					},
+					&discord.StringOption{
+						OptionName:  "diff",
+						Description: "The difficulty of the chart",
+						Required:    true,
+						Choices:     diffChoices,
+					},
 				},
-				&discord.StringOption{
-					OptionName:  "diff",
-					Description: "The difficulty of the chart",
-					Required:    true,
-					Choices:     diffChoices,
+			},
+			Handler: leaderboard.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"leaderboard": leaderboard.HandleLeaderboardPageSelect,
+			},
+			GuildOnly:    true,
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "ranking",
+				Description: "Ranks the members of this server by their b30 average",
+			},
+			Handler: ranking.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"ranking": ranking.HandleRankingPageSelect,
+			},
+			GuildOnly:    true,
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "stats",
+				Description: "Shows statistics of your saved scores by level and difficulty",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "from",
+						Description: "Only count the scores saved from this date (YYYY-MM-DD, in your timezone)",
+						Required:    false,
+					},
+					&discord.StringOption{
+						OptionName:  "to",
+						Description: "Only count the scores saved until this date (YYYY-MM-DD, in your timezone)",
+						Required:    false,
+					},
 				},
-				&discord.IntegerOption{
-					OptionName:  "score",
-					Description: "The score of the play, supports short score format (e.g. 980 instead of 9800000)",
-					Required:    true,
+			},
+			Handler: stats.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"stats": stats.HandleStatsPageSelect,
+			},
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "progress",
+				Description: "Shows your progress on the charts of a level",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "level",
+						Description: "The level of the charts",
+						Required:    true,
+						Choices:     levelChoices,
+					},
+					&discord.StringOption{
+						OptionName:  "diff",
+						Description: "Only show the charts of this difficulty",
+						Required:    false,
+						Choices:     diffChoices,
+					},
+					&discord.StringOption{
+						OptionName:  "threshold",
+						Description: "The grade (e.g. EX+) or score a chart needs to be done, defaults to EX",
+						Required:    false,
+					},
 				},
 			},
+			Handler: progress.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"progress": progress.HandleProgressPageSelect,
+			},
+			UsesDatabase: true,
 		},
 		{
-			Name:        "random",
-			Description: "Returns a random song",
-			Options: []discord.CommandOption{
-				&discord.StringOption{
-					OptionName:  "level",
-					Description: "The level of the chart",
-					Required:    false,
-					Choices:     levelChoices,
+			Schema: api.CreateCommandData{
+				Name:        "session",
+				Description: "Groups the scores you save during a practice session",
+				Options: []discord.CommandOption{
+					&discord.SubcommandOption{
+						OptionName:  "start",
+						Description: "Starts a practice session",
+					},
+					&discord.SubcommandOption{
+						OptionName:  "end",
+						Description: "Ends the current practice session and shows a summary",
+					},
 				},
-				&discord.StringOption{
-					OptionName:  "diff",
-					Description: "The difficulty of the chart",
-					Required:    false,
-					Choices:     diffChoices,
+			},
+			Handler:      NewPracticeSessionHandler(store, db, datasvcs.SongData()).HandleSlashCommand,
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "save-batch",
+				Description: "Saves multiple scores at once",
+			},
+			Handler: saveBatch.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"save_batch": saveBatch.HandleSaveBatchConfirm,
+			},
+			Modals: map[string]InteractionHandler{
+				"save_batch": saveBatch.HandleSaveBatchModalSubmit,
+			},
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "forecast",
+				Description: "Estimates when you will reach a potential based on your progress",
+				Options: []discord.CommandOption{
+					&discord.NumberOption{
+						OptionName:  "target",
+						Description: "The potential to reach, e.g. 12.50",
+						Required:    true,
+					},
 				},
 			},
+			Handler:      NewForecastHandler(store, db, datasvcs.SongData()).HandleSlashCommand,
+			UsesDatabase: true,
 		},
 		{
-			Name:        "b30",
-			Description: "Shows your top scores alongside a b30 summary",
+			Schema: api.CreateCommandData{
+				Name:        "trash",
+				Description: "Shows your deleted scores, which can be restored before they are permanently deleted",
+			},
+			Handler: trash.HandleSlashCommand,
+			Components: map[string]InteractionHandler{
+				"trash":         trash.HandleTrashPageSelect,
+				"trash_restore": trash.HandleTrashRestore,
+			},
+			UsesDatabase: true,
 		},
 		{
-			Name:        "scores",
-			Description: "Shows the scores you saved for a song",
-			Options: []discord.CommandOption{
-				&discord.StringOption{
-					OptionName:  "song",
-					Description: "Search term for the song",
-					Required:    true,
+			Schema: api.CreateCommandData{
+				Name:        "edit",
+				Description: "Edits the score and timestamp of a saved score",
+				Options: []discord.CommandOption{
+					&discord.IntegerOption{
+						OptionName:  "score_id",
+						Description: "The ID of the score to edit",
+						Required:    true,
+					},
 				},
-				&discord.StringOption{
-					OptionName:  "diff",
-					Description: "The difficulty of the chart",
-					Required:    true,
-					Choices:     diffChoices,
+			},
+			Handler: edit.HandleSlashCommand,
+			Modals: map[string]InteractionHandler{
+				"edit_score": edit.HandleEditModalSubmit,
+			},
+			UsesDatabase: true,
+		},
+		{
+			Schema: api.CreateCommandData{
+				Name:        "history",
+				Description: "Shows how your b30 average and estimated potential changed over time",
+				Options: []discord.CommandOption{
+					&discord.StringOption{
+						OptionName:  "from",
+						Description: "Only show the history from this date (YYYY-MM-DD, in your timezone)",
+						Required:    false,
+					},
+					&discord.StringOption{
+						OptionName:  "to",
+						Description: "Only show the history until this date (YYYY-MM-DD, in your timezone)",
+						Required:    false,
+					},
 				},
 			},
+			Handler:      NewHistoryHandler(store, db, datasvcs.SongData()).HandleSlashCommand,
+			UsesDatabase: true,
 		},
 	}
+}
 
-	err := cmdroute.OverwriteCommands(st, cmds)
+// Registers the schemas of every command in the registry to Discord.
+func RegisterCommands(ctx context.Context, st *state.State, registry *Registry) {
+	err := cmdroute.OverwriteCommands(st, registry.GetSchemas())
 	if err != nil {
 		logger.Fatal(ctx, "failed to register slash commands")
 	}
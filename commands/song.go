@@ -26,18 +26,7 @@ func NewSongHandler(store *store.Store, songdata *songdata.Service) *songHandler
 }
 
 func (h *songHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
-	var data *discord.CommandInteraction
-
-	switch e.Data.(type) {
-	case *discord.CommandInteraction:
-		data = e.Data.(*discord.CommandInteraction)
-	default:
-		return false
-	}
-
-	if data.Name != "song" {
-		return false
-	}
+	data := e.Data.(*discord.CommandInteraction)
 
 	st := h.store.Bot.State()
 
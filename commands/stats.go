@@ -0,0 +1,288 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type statsHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewStatsHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *statsHandler {
+	return &statsHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+var statsSections = []string{"Overview", "By level", "By difficulty"}
+
+var statsDiffKeys = []string{"pst", "prs", "ftr", "etr", "byd"}
+
+var statsGrades = []string{"EX+", "EX", "AA", "A", "B", "C", "D"}
+
+type statsGroup struct {
+	Played   int
+	Total    int
+	ScoreSum int
+	Grades   map[string]int
+}
+
+type userStats struct {
+	Plays        int
+	Overall      statsGroup
+	Levels       map[string]*statsGroup
+	Diffs        map[string]*statsGroup
+	HighestSong  songdata.Song
+	HighestChart songdata.Chart
+	HighestScore database.ScoreRecord
+	HasHighest   bool
+}
+
+func (h *statsHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	data := e.Data.(*discord.CommandInteraction)
+
+	st := h.store.Bot.State()
+
+	sess := database.GetSession(ctx)
+
+	loc, err := getUserLocation(ctx, int64(e.Sender().ID))
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	from, to, errStr, ok := parseDateRangeOptions(data.Options, loc)
+	if !ok {
+		sendCommandErrorReply(st, errStr, e)
+		return true
+	}
+
+	userId := int64(e.Sender().ID)
+
+	stats, err := getUserStats(ctx, h, sess.GetScoresRepo(), userId, from, to)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	if stats.Plays == 0 {
+		sendCommandErrorReply(st, "You don't have any scores saved in this date range!", e)
+		return true
+	}
+
+	embed := createStatsEmbed(stats, 0)
+	components := createStatsPageButtons(userId, from, to, 0)
+
+	sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
+
+	return true
+}
+
+func (h *statsHandler) HandleStatsPageSelect(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	val := e.Data.(*discord.ButtonInteraction).CustomID
+
+	params := strings.Split(string(val), ",")
+
+	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	section, _ := strconv.Atoi(params[2])
+	from, _ := strconv.ParseInt(params[3], 10, 64)
+	to, _ := strconv.ParseInt(params[4], 10, 64)
+
+	sess := database.GetSession(ctx)
+
+	stats, err := getUserStats(ctx, h, sess.GetScoresRepo(), userId, from, to)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	embed := createStatsEmbed(stats, section)
+	components := createStatsPageButtons(userId, from, to, section)
+
+	resp := api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Embeds:     &[]discord.Embed{embedbuilder.Info(embed)},
+			Components: (*discord.TopLevelComponents)(&components),
+		},
+	}
+
+	st.RespondInteraction(e.ID, e.Token, resp)
+
+	return true
+}
+
+// Aggregates the best score of each chart played within the [from, to) range. The totals count every chart in the
+// song data, regardless of the range.
+func getUserStats(ctx context.Context, h *statsHandler, repo *database.ScoresRepo, userId int64, from int64, to int64) (userStats, error) {
+	scores, err := repo.GetByUser(ctx, userId)
+	if err != nil {
+		return userStats{}, err
+	}
+
+	scores = slices.DeleteFunc(scores, func(s database.ScoreRecord) bool {
+		return s.Timestamp < from || s.Timestamp >= to
+	})
+
+	stats := userStats{
+		Plays:   len(scores),
+		Overall: statsGroup{Grades: make(map[string]int)},
+		Levels:  make(map[string]*statsGroup),
+		Diffs:   make(map[string]*statsGroup),
+	}
+
+	best := getBestScoresByChart(scores)
+
+	for _, song := range h.songdata.GetData() {
+		for _, chart := range song.Charts {
+			groups := []*statsGroup{&stats.Overall, getStatsGroup(stats.Levels, chart.Level), getStatsGroup(stats.Diffs, chart.Diff)}
+
+			s, played := best[chart.Id]
+			for _, g := range groups {
+				g.Total++
+				if played {
+					g.Played++
+					g.ScoreSum += s.Score
+					g.Grades[getScoreGrade(s.Score)]++
+				}
+			}
+
+			// scores do not record the clear type, so any saved score counts as a clear.
+			if played && (!stats.HasHighest || chart.CC > stats.HighestChart.CC) {
+				stats.HighestSong = song
+				stats.HighestChart = chart
+				stats.HighestScore = s
+				stats.HasHighest = true
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+func getStatsGroup(groups map[string]*statsGroup, key string) *statsGroup {
+	g, ok := groups[key]
+	if !ok {
+		g = &statsGroup{Grades: make(map[string]int)}
+		groups[key] = g
+	}
+
+	return g
+}
+
+func createStatsEmbed(stats userStats, section int) discord.Embed {
+	fields := make([]discord.EmbedField, 0)
+
+	switch section {
+	case 0:
+		fields = append(fields,
+			discord.EmbedField{
+				Name:  "Plays",
+				Value: fmt.Sprintf("%v scores saved\n%v / %v charts played\nAverage score: %s", stats.Plays, stats.Overall.Played, stats.Overall.Total, getStatsAverageString(&stats.Overall)),
+			},
+			discord.EmbedField{
+				Name:  "Grades",
+				Value: getStatsGradesString(&stats.Overall),
+			},
+		)
+
+		if stats.HasHighest {
+			fields = append(fields, discord.EmbedField{
+				Name:  "Highest cc chart cleared",
+				Value: fmt.Sprintf("%s ▸ %s Lv%s (%s)\n%v (Play Rating %s)", stats.HighestSong.EscapedAltTitle(), stats.HighestChart.GetDiffDisplayName(), stats.HighestChart.Level, stats.HighestChart.GetCCString(), stats.HighestScore.Score, stats.HighestChart.GetScoreRatingString(stats.HighestScore.Score)),
+			})
+		}
+	case 1:
+		levels := make([]string, 0, len(stats.Levels))
+		for lv := range stats.Levels {
+			levels = append(levels, lv)
+		}
+		slices.SortFunc(levels, compareLevels)
+
+		for _, lv := range levels {
+			g := stats.Levels[lv]
+			fields = append(fields, discord.EmbedField{
+				Name:   fmt.Sprintf("Lv%s", lv),
+				Value:  fmt.Sprintf("%v / %v played\nAvg %s", g.Played, g.Total, getStatsAverageString(g)),
+				Inline: true,
+			})
+		}
+	case 2:
+		for _, diff := range statsDiffKeys {
+			g, ok := stats.Diffs[diff]
+			if !ok {
+				continue
+			}
+
+			fields = append(fields, discord.EmbedField{
+				Name:  getFullDiffName(diff),
+				Value: fmt.Sprintf("%v / %v played - Avg %s\n%s", g.Played, g.Total, getStatsAverageString(g), getStatsGradesString(g)),
+			})
+		}
+	}
+
+	embed := discord.Embed{
+		Title:  fmt.Sprintf("Statistics ▸ %s", statsSections[section]),
+		Fields: fields,
+		Footer: &discord.EmbedFooter{
+			Text: fmt.Sprintf("Page %v of %v. Only the best score of each chart is counted.", section+1, len(statsSections)),
+		},
+	}
+
+	return embed
+}
+
+func getStatsAverageString(g *statsGroup) string {
+	if g.Played == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.0f", float64(g.ScoreSum)/float64(g.Played))
+}
+
+func getStatsGradesString(g *statsGroup) string {
+	gradesStr := make([]string, 0, len(statsGrades))
+	for _, grade := range statsGrades {
+		gradesStr = append(gradesStr, fmt.Sprintf("%s: %v", grade, g.Grades[grade]))
+	}
+
+	return strings.Join(gradesStr, " | ")
+}
+
+func createStatsPageButtons(userId int64, from int64, to int64, section int) []discord.TopLevelComponent {
+	prevSection := section - 1
+	nextSection := section + 1
+
+	return []discord.TopLevelComponent{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,stats,%v,%v,%v", userId, prevSection, from, to)),
+				Label:    "<",
+				Disabled: prevSection < 0,
+			},
+			&discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,stats,%v,%v,%v", userId, nextSection, from, to)),
+				Label:    ">",
+				Disabled: nextSection >= len(statsSections),
+			},
+		},
+	}
+}
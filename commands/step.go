@@ -26,18 +26,7 @@ func NewStepHandler(store *store.Store, songdata *songdata.Service) *stepHandler
 }
 
 func (h *stepHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
-	var data *discord.CommandInteraction
-
-	switch e.Data.(type) {
-	case *discord.CommandInteraction:
-		data = e.Data.(*discord.CommandInteraction)
-	default:
-		return false
-	}
-
-	if data.Name != "step" {
-		return false
-	}
+	data := e.Data.(*discord.CommandInteraction)
 
 	st := h.store.Bot.State()
 
@@ -68,7 +57,40 @@ func (h *stepHandler) HandleSlashCommand(ctx context.Context, e *gateway.Interac
 		return true
 	}
 
-	score, errStr, ok := parseShortScore(data.Options.Find("score").String())
+	boosts, errStr, ok := parseStepBoostOptions(data.Options)
+	if !ok {
+		sendCommandErrorReply(st, errStr, e)
+		return true
+	}
+
+	scoreStr := data.Options.Find("score").String()
+	targetStr := data.Options.Find("target").String()
+
+	switch {
+	case scoreStr != "" && targetStr != "":
+		sendCommandErrorReply(st, "Only one of `score` and `target` can be given!", e)
+		return true
+	case scoreStr == "" && targetStr == "":
+		sendCommandErrorReply(st, "Either `score` or `target` is needed to calculate the progress!", e)
+		return true
+	case targetStr != "":
+		target, err := data.Options.Find("target").FloatValue()
+		if err != nil || target <= 0 {
+			sendCommandErrorReply(st, fmt.Sprintf("Invalid target progress `%s`!", targetStr), e)
+			return true
+		}
+
+		if step <= 0 {
+			sendCommandErrorReply(st, "The STEP stat must be positive to reach a target progress!", e)
+			return true
+		}
+
+		res := embedbuilder.Info(createStepTargetEmbed(song, chart, step, boosts, target))
+		sendInteractionResponse(st, res, []discord.TopLevelComponent{}, e)
+		return true
+	}
+
+	score, errStr, ok := parseShortScore(scoreStr)
 	if !ok {
 		sendCommandErrorReply(st, errStr, e)
 		return true
@@ -76,9 +98,8 @@ func (h *stepHandler) HandleSlashCommand(ctx context.Context, e *gateway.Interac
 
 	ptt := chart.GetActualScoreRating(score)
 
-	progress := (2.45*math.Sqrt(ptt) + 2.5) * (float64(step) / 50)
-	floored := math.Floor(progress*10) / 10
-	formula := fmt.Sprintf("(2.45 * sqrt(%.4f) + 2.5) * (%v / 50) = **%.4f** (shown as **%.1f**)", ptt, step, progress, floored)
+	progress := getBoostedStepProgress(ptt, step, boosts)
+	formula := fmt.Sprintf("%s = **%.1f**", getStepFormula(ptt, step, boosts), progress)
 
 	embed := discord.Embed{
 		Fields: []discord.EmbedField{
@@ -110,14 +131,200 @@ func (h *stepHandler) HandleSlashCommand(ctx context.Context, e *gateway.Interac
 				Value: fmt.Sprintf(`%s
 
 -# - There might be a ±0.1 difference in actual progress gained due to differences in calculation performed by the game.
--# - For partner progression bonuses, __add__ them to the value above before calculating Play+ and fragment boosts.
--# - For Play+ boost, __multiply__ the value by stamina used. For fragment boost, further __multiply__ the value by boost multiplier.`, formula),
+-# - Partner progression bonuses are added before Play+, fragment boosts and map modifiers are multiplied, and the result is rounded down to 0.1 as shown in the game.`, formula),
 			},
 		},
 	}
 
+	if msg, ok := getUnachievableScoreMessage(chart, score); ok && isFullScoreInput(scoreStr) {
+		embed.Description = fmt.Sprintf("-# %s", msg)
+	}
+
 	res := embedbuilder.Info(embed)
 	sendInteractionResponse(st, res, []discord.TopLevelComponent{}, e)
 
 	return true
 }
+
+func createStepTargetEmbed(song songdata.Song, chart songdata.Chart, step float64, boosts stepBoosts, target float64) discord.Embed {
+	// the game shows progress rounded down to 0.1, so a target in between can only be reached at the next 0.1.
+	target = math.Ceil(target*10-1e-9) / 10
+
+	embed := discord.Embed{
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Song",
+				Value: fmt.Sprintf("%s - %s", song.EscapedTitle(), song.EscapedArtist()),
+			},
+			{
+				Name:  "Chart",
+				Value: fmt.Sprintf("%s - Lv%s (%.1f) (v%s)", chart.GetDiffDisplayName(), chart.Level, chart.CC, chart.Ver),
+			},
+			{
+				Name:   "Target progress",
+				Value:  fmt.Sprintf("%.1f", target),
+				Inline: true,
+			},
+			{
+				Name:   "Step stat",
+				Value:  strconv.FormatFloat(step, 'f', -1, 64),
+				Inline: true,
+			},
+		},
+	}
+
+	score, ok := getMinScoreForStepProgress(chart, step, boosts, target)
+	if !ok {
+		maxPtt := chart.GetActualScoreRating(10000000)
+		embed.Fields = append(embed.Fields, discord.EmbedField{
+			Name:  "Minimum score",
+			Value: fmt.Sprintf("Unreachable on this chart! A Pure Memory gives at most **%.1f**.\n-# %s = %.1f", getBoostedStepProgress(maxPtt, step, boosts), getStepFormula(maxPtt, step, boosts), getBoostedStepProgress(maxPtt, step, boosts)),
+		})
+		return embed
+	}
+
+	ptt := chart.GetActualScoreRating(score)
+
+	scoreDesc := fmt.Sprintf("**%v** (%s)", score, getScoreGrade(score))
+	if score == 0 {
+		scoreDesc = "Any score reaches the target!"
+	}
+
+	embed.Fields = append(embed.Fields, discord.EmbedField{
+		Name:  "Minimum score",
+		Value: fmt.Sprintf("%s\n-# %s = %.1f", scoreDesc, getStepFormula(ptt, step, boosts), getBoostedStepProgress(ptt, step, boosts)),
+	})
+
+	return embed
+}
+
+// Returns the minimum score on the chart that gives at least the target progress with the given boosts, by solving
+// the step formula for the play rating. Returns false if even a Pure Memory does not reach the target.
+func getMinScoreForStepProgress(chart songdata.Chart, step float64, boosts stepBoosts, target float64) (int, bool) {
+	base := target/(float64(boosts.PlayPlus)*boosts.FragmentBoost*boosts.Modifier) - boosts.PartnerBonus
+
+	rating := 0.0
+	sqrtPtt := (base*50/step - 2.5) / 2.45
+	if sqrtPtt > 0 {
+		rating = sqrtPtt * sqrtPtt
+	}
+
+	score, ok := chart.GetMinScoreForRating(rating)
+	if !ok {
+		return 0, false
+	}
+
+	reaches := func(s int) bool {
+		return getBoostedStepProgress(chart.GetActualScoreRating(s), step, boosts) >= target-1e-9
+	}
+
+	// the solved score can be off by a few points because of floating point errors, so it is adjusted against the
+	// forward calculation.
+	for score > 0 && reaches(score-1) {
+		score--
+	}
+
+	for !reaches(score) {
+		if score >= 10000000 {
+			return 0, false
+		}
+		score++
+	}
+
+	return score, true
+}
+
+type stepBoosts struct {
+	// the flat progression bonus of the partner, added before the multipliers.
+	PartnerBonus float64
+	// the stamina multiplier of Play+, 1 if it is not used.
+	PlayPlus int64
+	// the fragment boost multiplier, 1 if it is not used.
+	FragmentBoost float64
+	// the multiplier of the map, such as for Legacy or Memory Archive maps, 1 if there is none.
+	Modifier float64
+}
+
+// the fragments used for each play by each fragment boost.
+var fragmentBoostCosts = map[float64]int{
+	1.1:  100,
+	1.25: 250,
+	1.5:  500,
+}
+
+// Parses the optional partner_bonus, play_plus, fragment_boost and modifier options, defaulting to no boosts.
+func parseStepBoostOptions(opts discord.CommandInteractionOptions) (stepBoosts, string, bool) {
+	boosts := stepBoosts{PlayPlus: 1, FragmentBoost: 1, Modifier: 1}
+
+	if opts.Find("partner_bonus").String() != "" {
+		bonus, err := opts.Find("partner_bonus").FloatValue()
+		if err != nil || bonus < 0 {
+			return stepBoosts{}, fmt.Sprintf("Invalid partner bonus `%s`!", opts.Find("partner_bonus").String()), false
+		}
+		boosts.PartnerBonus = bonus
+	}
+
+	if opts.Find("play_plus").String() != "" {
+		playPlus, err := opts.Find("play_plus").IntValue()
+		if err != nil || playPlus < 1 || playPlus > 6 {
+			return stepBoosts{}, fmt.Sprintf("Invalid Play+ multiplier `%s`!", opts.Find("play_plus").String()), false
+		}
+		boosts.PlayPlus = playPlus
+	}
+
+	if opts.Find("fragment_boost").String() != "" {
+		boost, err := opts.Find("fragment_boost").FloatValue()
+		if _, ok := fragmentBoostCosts[boost]; err != nil || !ok {
+			return stepBoosts{}, fmt.Sprintf("Invalid fragment boost `%s`!", opts.Find("fragment_boost").String()), false
+		}
+		boosts.FragmentBoost = boost
+	}
+
+	if opts.Find("modifier").String() != "" {
+		modifier, err := opts.Find("modifier").FloatValue()
+		if err != nil || modifier <= 0 {
+			return stepBoosts{}, fmt.Sprintf("Invalid map modifier `%s`!", opts.Find("modifier").String()), false
+		}
+		boosts.Modifier = modifier
+	}
+
+	return boosts, "", true
+}
+
+// Returns the progress gained with all bonuses and boosts applied, rounded down to 0.1 like the game does after the
+// multipliers are applied.
+func getBoostedStepProgress(ptt float64, step float64, boosts stepBoosts) float64 {
+	progress := (getStepProgress(ptt, step) + boosts.PartnerBonus) * float64(boosts.PlayPlus) * boosts.FragmentBoost * boosts.Modifier
+
+	// the small epsilon keeps values such as 10.3 * 3 from being floored to 30.8 because of floating point errors.
+	return math.Floor(progress*10+1e-9) / 10
+}
+
+// Returns the breakdown of the progress calculation, leaving out the bonuses and boosts that are not used.
+func getStepFormula(ptt float64, step float64, boosts stepBoosts) string {
+	formula := fmt.Sprintf("(2.45 * sqrt(%.4f) + 2.5) * (%v / 50)", ptt, step)
+
+	if boosts.PartnerBonus != 0 {
+		formula = fmt.Sprintf("(%s + %v)", formula, boosts.PartnerBonus)
+	}
+
+	if boosts.PlayPlus != 1 {
+		formula += fmt.Sprintf(" * %v", boosts.PlayPlus)
+	}
+
+	if boosts.FragmentBoost != 1 {
+		formula += fmt.Sprintf(" * %v", boosts.FragmentBoost)
+	}
+
+	if boosts.Modifier != 1 {
+		formula += fmt.Sprintf(" * %v", boosts.Modifier)
+	}
+
+	return formula
+}
+
+// Returns the progress gained in World Mode from a play with the given play rating and partner STEP stat, before any
+// bonuses and boosts are applied.
+func getStepProgress(ptt float64, step float64) float64 {
+	return (2.45*math.Sqrt(ptt) + 2.5) * (step / 50)
+}
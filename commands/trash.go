@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/api"
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
+	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/store"
+)
+
+type trashHandler struct {
+	store    *store.Store
+	db       *database.Service
+	songdata *songdata.Service
+}
+
+func NewTrashHandler(store *store.Store, db *database.Service, songdata *songdata.Service) *trashHandler {
+	return &trashHandler{
+		store:    store,
+		db:       db,
+		songdata: songdata,
+	}
+}
+
+func (h *trashHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	sess := database.GetSession(ctx)
+
+	scoresRepo := sess.GetScoresRepo()
+
+	count, err := scoresRepo.GetTrashedCountByUser(ctx, int64(e.Sender().ID))
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	if count == 0 {
+		sendCommandErrorReply(st, "You don't have any deleted scores in the trash!", e)
+		return true
+	}
+
+	entries, err := scoresRepo.GetTrashedByUserWithOffset(ctx, int64(e.Sender().ID), 0, 5)
+	if err != nil {
+		logAndSendCommandError(ctx, st, err, e)
+		return true
+	}
+
+	embed := createTrashEmbed(h, entries, 0)
+	components := createTrashButtons(int64(e.Sender().ID), entries, count, 0)
+
+	sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
+
+	return true
+}
+
+func (h *trashHandler) HandleTrashPageSelect(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	val := e.Data.(*discord.ButtonInteraction).CustomID
+
+	params := strings.Split(string(val), ",")
+
+	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	offset, _ := strconv.Atoi(params[2])
+
+	updateTrashPage(ctx, h, userId, offset, 0, e)
+
+	return true
+}
+
+func (h *trashHandler) HandleTrashRestore(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	val := e.Data.(*discord.ButtonInteraction).CustomID
+
+	params := strings.Split(string(val), ",")
+
+	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	id, _ := strconv.ParseInt(params[2], 10, 64)
+	offset, _ := strconv.Atoi(params[3])
+
+	updateTrashPage(ctx, h, userId, offset, id, e)
+
+	return true
+}
+
+// Updates the trash listing message to show the page at the given offset, restoring the score with the given id
+// beforehand if it is not 0.
+func updateTrashPage(ctx context.Context, h *trashHandler, userId int64, offset int, restoreId int64, e *gateway.InteractionCreateEvent) {
+	st := h.store.Bot.State()
+
+	sess := database.GetSession(ctx)
+
+	scoresRepo := sess.GetScoresRepo()
+
+	if restoreId != 0 {
+		_, ok, err := restoreScore(ctx, sess, h.db.RetentionPolicy(), userId, restoreId)
+		if err != nil {
+			logAndSendInteractionError(ctx, st, err, e)
+			return
+		}
+
+		if !ok {
+			sendInteractionReply(st, embedbuilder.UserError(fmt.Sprintf("Score with ID `%v` is no longer in the trash!", restoreId)), e)
+			return
+		}
+	}
+
+	count, err := scoresRepo.GetTrashedCountByUser(ctx, userId)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return
+	}
+
+	// the page might be gone after a restore, move back to the last page if it is.
+	for offset > 0 && offset >= count {
+		offset -= 5
+	}
+
+	entries, err := scoresRepo.GetTrashedByUserWithOffset(ctx, userId, offset, 5)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return
+	}
+
+	embed := createTrashEmbed(h, entries, offset)
+	components := createTrashButtons(userId, entries, count, offset/5)
+
+	resp := api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Embeds:     &[]discord.Embed{embedbuilder.Info(embed)},
+			Components: (*discord.TopLevelComponents)(&components),
+		},
+	}
+
+	st.RespondInteraction(e.ID, e.Token, resp)
+}
+
+func createTrashEmbed(h *trashHandler, entries []database.TrashedScoreRecord, idx int) discord.Embed {
+	if len(entries) == 0 {
+		return discord.Embed{
+			Title:       "Deleted Scores",
+			Description: "Your trash is empty!",
+		}
+	}
+
+	entriesBuilder := strings.Builder{}
+	retention := h.db.TrashRetention()
+
+	for i, s := range entries {
+		chart, song, _ := h.songdata.GetChartById(s.ChartId)
+		purgeAt := time.UnixMilli(s.DeletedAt).Add(retention)
+
+		fmt.Fprintf(&entriesBuilder, "%v. %v ▸ %v Lv%v\n  %v (<t:%v:R>)\n  -# Score ID: %v ‧ Deleted <t:%v:R>, removed permanently <t:%v:R>\n",
+			idx+i+1,
+			song.AltTitle,
+			chart.GetDiffDisplayName(),
+			chart.Level,
+			s.Score,
+			s.Timestamp/1000,
+			s.Id,
+			s.DeletedAt/1000,
+			purgeAt.Unix())
+	}
+
+	return discord.Embed{
+		Title: "Deleted Scores",
+		Fields: []discord.EmbedField{
+			{
+				Name:  "Trash",
+				Value: entriesBuilder.String(),
+			},
+		},
+	}
+}
+
+func createTrashButtons(userId int64, entries []database.TrashedScoreRecord, count int, pageIdx int) []discord.TopLevelComponent {
+	offset := pageIdx * 5
+	prevOffset := (pageIdx - 1) * 5
+	nextOffset := (pageIdx + 1) * 5
+
+	res := []discord.TopLevelComponent{}
+
+	if len(entries) > 0 {
+		restoreRow := discord.ActionRowComponent{}
+		for _, s := range entries {
+			restoreRow = append(restoreRow, &discord.ButtonComponent{
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,trash_restore,%v,%v", userId, s.Id, offset)),
+				Label:    fmt.Sprintf("Restore %v", s.Id),
+				Style:    discord.SecondaryButtonStyle(),
+			})
+		}
+		res = append(res, &restoreRow)
+	}
+
+	res = append(res, &discord.ActionRowComponent{
+		&discord.ButtonComponent{
+			CustomID: discord.ComponentID(fmt.Sprintf("%v,trash,%v", userId, prevOffset)),
+			Label:    "<",
+			Disabled: prevOffset < 0,
+		},
+		&discord.ButtonComponent{
+			CustomID: discord.ComponentID(fmt.Sprintf("%v,trash,%v", userId, nextOffset)),
+			Label:    ">",
+			Disabled: nextOffset >= count,
+		},
+	})
+
+	return res
+}
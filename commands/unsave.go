@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/diamondburned/arikawa/v3/api"
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
 	"github.com/lilacse/kagura/database"
 	"github.com/lilacse/kagura/dataservices/songdata"
 	"github.com/lilacse/kagura/embedbuilder"
+	"github.com/lilacse/kagura/logger"
 	"github.com/lilacse/kagura/store"
 )
 
@@ -28,18 +32,7 @@ func NewUnsaveHandler(store *store.Store, db *database.Service, songdata *songda
 }
 
 func (h *unsaveHandler) HandleSlashCommand(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
-	var data *discord.CommandInteraction
-
-	switch e.Data.(type) {
-	case *discord.CommandInteraction:
-		data = e.Data.(*discord.CommandInteraction)
-	default:
-		return false
-	}
-
-	if data.Name != "unsave" {
-		return false
-	}
+	data := e.Data.(*discord.CommandInteraction)
 
 	st := h.store.Bot.State()
 
@@ -51,18 +44,7 @@ func (h *unsaveHandler) HandleSlashCommand(ctx context.Context, e *gateway.Inter
 		return true
 	}
 
-	sess, err := h.db.NewSession(ctx)
-	if err != nil {
-		logAndSendCommandError(ctx, st, err, e)
-		return true
-	}
-
-	defer func() {
-		err := sess.Conn.Close()
-		if err != nil {
-			logAndSendCommandError(ctx, st, err, e)
-		}
-	}()
+	sess := database.GetSession(ctx)
 
 	tx, err := sess.Conn.BeginTx(ctx, nil)
 	if err != nil {
@@ -101,7 +83,7 @@ func (h *unsaveHandler) HandleSlashCommand(ctx context.Context, e *gateway.Inter
 		return true
 	}
 
-	_, err = scoresRepo.Delete(ctx, id)
+	_, err = scoresRepo.Trash(ctx, id, time.Now().UnixMilli())
 	if err != nil {
 		logAndSendCommandError(ctx, st, err, e)
 		return true
@@ -115,8 +97,125 @@ func (h *unsaveHandler) HandleSlashCommand(ctx context.Context, e *gateway.Inter
 
 	isCommit = true
 
-	embed := discord.Embed{
-		Title: "Score deleted",
+	embed := createScoreRecordEmbed("Score deleted", song, chart, currRec)
+	embed.Footer = &discord.EmbedFooter{
+		Text: fmt.Sprintf("Deleted scores can be restored from /trash until they are permanently deleted %s.", getPurgeTimeDescription(h.db.TrashRetention())),
+	}
+
+	components := createUndoButtons(int64(e.Sender().ID), id)
+	sendInteractionResponse(st, embedbuilder.Info(embed), components, e)
+
+	return true
+}
+
+func (h *unsaveHandler) HandleUndo(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+	st := h.store.Bot.State()
+
+	val := e.Data.(*discord.ButtonInteraction).CustomID
+
+	params := strings.Split(string(val), ",")
+
+	userId, _ := strconv.ParseInt(params[0], 10, 64)
+	id, _ := strconv.ParseInt(params[2], 10, 64)
+
+	sess := database.GetSession(ctx)
+
+	rec, ok, err := restoreScore(ctx, sess, h.db.RetentionPolicy(), userId, id)
+	if err != nil {
+		logAndSendInteractionError(ctx, st, err, e)
+		return true
+	}
+
+	if !ok {
+		sendInteractionReply(st, embedbuilder.UserError(fmt.Sprintf("Score with ID `%v` is no longer in the trash!", id)), e)
+		return true
+	}
+
+	chart, song, ok := h.songdata.GetChartById(rec.ChartId)
+	if !ok {
+		logAndSendInteractionError(ctx, st, fmt.Errorf("chart id %v is not found in songdata", rec.ChartId), e)
+		return true
+	}
+
+	embed := embedbuilder.Info(createScoreRecordEmbed("Score restored", song, chart, rec))
+
+	resp := api.InteractionResponse{
+		Type: api.UpdateMessage,
+		Data: &api.InteractionResponseData{
+			Embeds:     &[]discord.Embed{embed},
+			Components: &discord.TopLevelComponents{},
+		},
+	}
+
+	st.RespondInteraction(e.ID, e.Token, resp)
+
+	return true
+}
+
+// Restores a trashed score if it belongs to the user. Returns false if there is no such score in the user's trash.
+// Restores a trashed score and applies the retention policy to its chart in a single transaction. The restored score
+// itself is exempted from the policy, but it can push other scores of the chart out of it.
+func restoreScore(ctx context.Context, sess *database.Session, policy database.RetentionPolicy, userId int64, id int64) (database.ScoreRecord, bool, error) {
+	tx, err := sess.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.ScoreRecord{}, false, err
+	}
+
+	isCommit := false
+
+	defer func() {
+		if !isCommit {
+			err := tx.Rollback()
+			if err != nil {
+				logger.Error(ctx, err.Error())
+			}
+		}
+	}()
+
+	scoresRepo := sess.GetScoresRepo()
+
+	recs, err := scoresRepo.GetTrashedById(ctx, id)
+	if err != nil {
+		return database.ScoreRecord{}, false, err
+	}
+
+	if len(recs) == 0 || recs[0].UserId != userId {
+		return database.ScoreRecord{}, false, nil
+	}
+
+	_, err = scoresRepo.Restore(ctx, id)
+	if err != nil {
+		return database.ScoreRecord{}, false, err
+	}
+
+	_, err = scoresRepo.EnforceRetention(ctx, userId, recs[0].ChartId, policy, time.Now(), id)
+	if err != nil {
+		return database.ScoreRecord{}, false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return database.ScoreRecord{}, false, err
+	}
+
+	isCommit = true
+	return recs[0], true, nil
+}
+
+func createUndoButtons(userId int64, id int64) []discord.TopLevelComponent {
+	return []discord.TopLevelComponent{
+		&discord.ActionRowComponent{
+			&discord.ButtonComponent{
+				Label:    "Undo",
+				CustomID: discord.ComponentID(fmt.Sprintf("%v,unsave_undo,%v", userId, id)),
+			},
+		},
+	}
+}
+
+func createScoreRecordEmbed(title string, song songdata.Song, chart songdata.Chart, rec database.ScoreRecord) discord.Embed {
+	return discord.Embed{
+		Title: title,
 		Fields: []discord.EmbedField{
 			{
 				Name:  "Song",
@@ -128,19 +227,27 @@ func (h *unsaveHandler) HandleSlashCommand(ctx context.Context, e *gateway.Inter
 			},
 			{
 				Name:   "Score",
-				Value:  strconv.Itoa(currRec.Score),
+				Value:  strconv.Itoa(rec.Score),
 				Inline: true,
 			},
 			{
 				Name:   "Timestamp",
-				Value:  fmt.Sprintf("<t:%v:R>", currRec.Timestamp/1000),
+				Value:  fmt.Sprintf("<t:%v:R>", rec.Timestamp/1000),
 				Inline: true,
 			},
 		},
 	}
+}
 
-	res := embedbuilder.Info(embed)
-	sendCommandReply(st, res, e)
+func getPurgeTimeDescription(retention time.Duration) string {
+	if retention <= 0 {
+		return "shortly"
+	}
 
-	return true
+	days := int(retention.Hours() / 24)
+	if days == 1 {
+		return "after 1 day"
+	}
+
+	return fmt.Sprintf("after %v days", days)
 }
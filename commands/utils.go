@@ -3,10 +3,19 @@ package commands
 import (
 	"context"
 	"fmt"
+	"math"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
+	// embeds the timezone database, as the host might not have one installed.
+	_ "time/tzdata"
 
+	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
 	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/dataservices/songdata"
 	"github.com/lilacse/kagura/embedbuilder"
 	"github.com/lilacse/kagura/logger"
 )
@@ -42,6 +51,67 @@ func parseFullScore(s string) (int, string, bool) {
 	return score, "", true
 }
 
+// Returns whether a score accepted by parseShortScore was given in full. Shortened scores are rounded, so they are not
+// checked against the note count of a chart.
+func isFullScoreInput(s string) bool {
+	score, err := strconv.Atoi(strings.TrimSpace(s))
+	return err == nil && score >= 1000000
+}
+
+// Returns a message if the score cannot be produced by any combination of judgments on the chart.
+func getUnachievableScoreMessage(chart songdata.Chart, score int) (string, bool) {
+	if chart.IsScoreAchievable(score) {
+		return "", false
+	}
+
+	maxScore, _ := chart.GetMaxScore()
+	return fmt.Sprintf("Score `%v` is not achievable on this chart! The maximum score is %v.", score, maxScore), true
+}
+
+var gradeThresholds = map[string]int{
+	"PM":  10000000,
+	"EX+": 9900000,
+	"EX":  9800000,
+	"AA":  9500000,
+	"A":   9200000,
+	"B":   8900000,
+	"C":   8600000,
+}
+
+// Parses a score threshold given either as a grade name (e.g. EX+) or as a score in the short score format.
+func parseScoreThreshold(s string) (int, string, bool) {
+	score, ok := gradeThresholds[strings.ToUpper(strings.TrimSpace(s))]
+	if ok {
+		return score, "", true
+	}
+
+	score, _, ok = parseShortScore(strings.TrimSpace(s))
+	if !ok {
+		return -1, fmt.Sprintf("Invalid threshold `%s`, expecting a grade (PM, EX+, EX, AA, A, B, C) or a score!", s), false
+	}
+
+	return score, "", true
+}
+
+func getScoreGrade(score int) string {
+	switch {
+	case score >= 9900000:
+		return "EX+"
+	case score >= 9800000:
+		return "EX"
+	case score >= 9500000:
+		return "AA"
+	case score >= 9200000:
+		return "A"
+	case score >= 8900000:
+		return "B"
+	case score >= 8600000:
+		return "C"
+	default:
+		return "D"
+	}
+}
+
 func parseScoreId(s string) (int64, bool) {
 	id, err := strconv.ParseInt(s, 10, 64)
 	if err != nil || id <= 0 {
@@ -51,6 +121,130 @@ func parseScoreId(s string) (int64, bool) {
 	return id, true
 }
 
+// Parses a date in the format of YYYY-MM-DD as the start of the day in the given location.
+func parseDate(s string, loc *time.Location) (time.Time, string, bool) {
+	t, err := time.ParseInLocation("2006-01-02", s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Sprintf("Invalid date `%s`, expecting the format YYYY-MM-DD!", s), false
+	}
+
+	return t, "", true
+}
+
+// Parses the optional "from" and "to" date options in the given location into a [from, to) range of unix
+// milliseconds. The "to" date is inclusive for the user, so the range ends at the start of the following day.
+func parseDateRangeOptions(opts discord.CommandInteractionOptions, loc *time.Location) (int64, int64, string, bool) {
+	from := int64(0)
+	to := int64(math.MaxInt64)
+
+	fromStr := opts.Find("from").String()
+	if fromStr != "" {
+		t, errStr, ok := parseDate(fromStr, loc)
+		if !ok {
+			return 0, 0, errStr, false
+		}
+		from = t.UnixMilli()
+	}
+
+	toStr := opts.Find("to").String()
+	if toStr != "" {
+		t, errStr, ok := parseDate(toStr, loc)
+		if !ok {
+			return 0, 0, errStr, false
+		}
+		to = t.AddDate(0, 0, 1).UnixMilli()
+	}
+
+	if from >= to {
+		return 0, 0, "The start date must not be later than the end date!", false
+	}
+
+	return from, to, "", true
+}
+
+// Orders levels by their number, with the "+" variant of a level right after it.
+func compareLevels(a string, b string) int {
+	aNum, _ := strconv.Atoi(strings.TrimSuffix(a, "+"))
+	bNum, _ := strconv.Atoi(strings.TrimSuffix(b, "+"))
+	if aNum != bNum {
+		return aNum - bNum
+	}
+
+	return strings.Compare(a, b)
+}
+
+// Returns the best score of each chart, with ties broken by the earliest timestamp.
+func getBestScoresByChart(scores []database.ScoreRecord) map[int]database.ScoreRecord {
+	best := make(map[int]database.ScoreRecord)
+
+	for _, s := range scores {
+		curr, ok := best[s.ChartId]
+		if !ok || s.Score > curr.Score || (s.Score == curr.Score && s.Timestamp < curr.Timestamp) {
+			best[s.ChartId] = s
+		}
+	}
+
+	return best
+}
+
+var playedAtLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+var relativeTimeRegex = regexp.MustCompile(`^(\d+)\s*(m|mins?|minutes?|h|hrs?|hours?|d|days?|w|weeks?)\s+ago$`)
+
+// Parses the time a score was played, either as an absolute date and time in the given location (e.g. 2024-01-02
+// 15:04) or as a time relative to now (e.g. 2h ago).
+func parsePlayedAt(s string, loc *time.Location, now time.Time) (time.Time, string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if m := relativeTimeRegex.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			return time.Time{}, fmt.Sprintf("Invalid time `%s`!", s), false
+		}
+
+		var unit time.Duration
+		switch m[2][0] {
+		case 'm':
+			unit = time.Minute
+		case 'h':
+			unit = time.Hour
+		case 'd':
+			unit = 24 * time.Hour
+		case 'w':
+			unit = 7 * 24 * time.Hour
+		}
+
+		return now.Add(-time.Duration(n) * unit), "", true
+	}
+
+	for _, layout := range playedAtLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return t, "", true
+		}
+	}
+
+	return time.Time{}, fmt.Sprintf("Invalid time `%s`, expecting YYYY-MM-DD hh:mm or a relative time like `2h ago`!", s), false
+}
+
+// Checks that the time a score was played is in the past and not before the chart could have been played.
+func validatePlayedAt(chart songdata.Chart, playedAt time.Time, now time.Time) (string, bool) {
+	if playedAt.After(now) {
+		return "The time played must not be in the future!", false
+	}
+
+	earliest := chart.GetEarliestPlayTime()
+	if playedAt.Before(earliest) {
+		return fmt.Sprintf("The time played must not be before <t:%v:D>, when Arcaea was first released!", earliest.Unix()), false
+	}
+
+	return "", true
+}
+
 func getFullDiffName(diffKey string) string {
 	switch diffKey {
 	case "pst":
@@ -0,0 +1,23 @@
+package database
+
+import "context"
+
+type ctxKey int
+
+const sessionKey ctxKey = iota
+
+// Returns a copy of the context carrying the session.
+func WithSession(ctx context.Context, sess *Session) context.Context {
+	return context.WithValue(ctx, sessionKey, sess)
+}
+
+// Returns the session carried by the context. Panics if there is none, as handlers are only given a session when their
+// command is registered as using the database.
+func GetSession(ctx context.Context) *Session {
+	sess, ok := ctx.Value(sessionKey).(*Session)
+	if !ok {
+		panic("no database session in context")
+	}
+
+	return sess
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+type GuildRankingsRepo struct {
+	conn *sql.Conn
+}
+
+func GetGuildRankingsRepo(conn *sql.Conn) *GuildRankingsRepo {
+	return &GuildRankingsRepo{conn: conn}
+}
+
+func (repo *GuildRankingsRepo) Join(ctx context.Context, guildId int64, userId int64) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`insert into guild_rankings (guild_id, user_id) values (?, ?) on conflict do nothing`,
+		guildId, userId,
+	)
+}
+
+func (repo *GuildRankingsRepo) Leave(ctx context.Context, guildId int64, userId int64) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`delete from guild_rankings where guild_id = ? and user_id = ?`,
+		guildId, userId,
+	)
+}
+
+func (repo *GuildRankingsRepo) IsJoined(ctx context.Context, guildId int64, userId int64) (bool, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		`select count(1) from guild_rankings where guild_id = ? and user_id = ?`,
+		guildId, userId,
+	)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer rows.Close()
+
+	var count int
+	rows.Next()
+	rows.Scan(&count)
+
+	return count > 0, nil
+}
+
+type GuildRankingRecord struct {
+	UserId       int64
+	AvgRating    float64
+	AvgScore     float64
+	TopChartId   int
+	TopScore     int
+	TopRating    float64
+	TopTimestamp int64
+}
+
+// computes the b30 average of every user that joined the rankings of a guild in a single pass, using the same rating
+// as SCORE_RATING_QUERY. only scores saved before the given timestamp are considered, so that past rankings can be
+// computed for comparison.
+const GUILD_B30_RANKING_QUERY string = `with best as (
+		select
+			scores.id,
+			scores.user_id,
+			scores.chart_id,
+			scores.score,
+			scores.timestamp,
+			row_number() over (partition by scores.user_id, scores.chart_id
+		order by
+			scores.score desc, scores.timestamp asc, scores.id asc) score_order
+		from
+			guild_rankings
+		inner join scores indexed by scores_idx on
+			scores.user_id = guild_rankings.user_id
+		where
+			guild_rankings.guild_id = ?
+			and scores.deleted_at is null
+			and scores.timestamp < ?
+	),
+	rated as (
+		select
+			best.user_id,
+			best.chart_id,
+			best.score,
+			best.timestamp,
+			` + SCORE_RATING_EXPR + ` rating
+		from
+			best
+		inner join charts on
+			best.chart_id = charts.id
+		where
+			best.score_order = 1
+	),
+	ordered as (
+		select
+			*,
+			row_number() over (partition by user_id
+		order by
+			rating desc, timestamp asc) rating_order
+		from
+			rated
+	)
+	select
+		user_id,
+		avg(rating) avg_rating,
+		avg(score),
+		max(case when rating_order = 1 then chart_id end),
+		max(case when rating_order = 1 then score end),
+		max(case when rating_order = 1 then rating end),
+		max(case when rating_order = 1 then timestamp end)
+	from
+		ordered
+	where
+		rating_order <= 30
+	group by
+		user_id
+	order by
+		avg_rating desc,
+		user_id asc`
+
+func (repo *GuildRankingsRepo) GetB30Rankings(ctx context.Context, guildId int64, before int64) ([]GuildRankingRecord, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		GUILD_B30_RANKING_QUERY,
+		guildId, before,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]GuildRankingRecord, 0)
+
+	for rows.Next() {
+		r := GuildRankingRecord{}
+		err := rows.Scan(&r.UserId, &r.AvgRating, &r.AvgScore, &r.TopChartId, &r.TopScore, &r.TopRating, &r.TopTimestamp)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+type LeaderboardEntry struct {
+	ScoreRecord
+	Rank int
+}
+
+// ranks the best score of each user that joined the rankings of a guild on a chart. each user's scores are looked up
+// through scores_idx (user_id, chart_id), which is forced as the planner otherwise prefers scores_deleted_idx. ties
+// are broken by the earliest timestamp, then the lowest id.
+const CHART_LEADERBOARD_QUERY string = `with best as (
+		select
+			id,
+			user_id,
+			chart_id,
+			score,
+			timestamp
+		from
+			(
+			select
+				scores.id,
+				scores.user_id,
+				scores.chart_id,
+				scores.score,
+				scores.timestamp,
+				row_number() over (partition by scores.user_id
+			order by
+				scores.score desc, scores.timestamp asc, scores.id asc) best_order
+			from
+				guild_rankings
+			inner join scores indexed by scores_idx on
+				scores.user_id = guild_rankings.user_id
+				and scores.chart_id = ?
+			where
+				guild_rankings.guild_id = ?
+				and scores.deleted_at is null
+		)
+		where
+			best_order = 1
+	),
+	ranked as (
+		select
+			id,
+			user_id,
+			chart_id,
+			score,
+			timestamp,
+			row_number() over (
+			order by score desc, timestamp asc, id asc) rank
+		from
+			best
+	)`
+
+type LeaderboardsRepo struct {
+	conn *sql.Conn
+}
+
+func GetLeaderboardsRepo(conn *sql.Conn) *LeaderboardsRepo {
+	return &LeaderboardsRepo{conn: conn}
+}
+
+func (repo *LeaderboardsRepo) GetChartLeaderboardWithOffset(ctx context.Context, guildId int64, chartId int, offset int, limit int) ([]LeaderboardEntry, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		CHART_LEADERBOARD_QUERY+` select id, user_id, chart_id, score, timestamp, rank from ranked order by rank limit ? offset ?`,
+		chartId, guildId, limit, offset,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanToLeaderboardEntries(rows)
+}
+
+func (repo *LeaderboardsRepo) GetChartLeaderboardCount(ctx context.Context, guildId int64, chartId int) (int, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		CHART_LEADERBOARD_QUERY+` select count(1) from ranked`,
+		chartId, guildId,
+	)
+
+	if err != nil {
+		return -1, err
+	}
+
+	defer rows.Close()
+
+	var count int
+	rows.Next()
+	rows.Scan(&count)
+
+	return count, nil
+}
+
+func (repo *LeaderboardsRepo) GetChartLeaderboardEntryByUser(ctx context.Context, guildId int64, chartId int, userId int64) ([]LeaderboardEntry, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		CHART_LEADERBOARD_QUERY+` select id, user_id, chart_id, score, timestamp, rank from ranked where user_id = ?`,
+		chartId, guildId, userId,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanToLeaderboardEntries(rows)
+}
+
+func scanToLeaderboardEntries(rows *sql.Rows) ([]LeaderboardEntry, error) {
+	res := make([]LeaderboardEntry, 0)
+
+	for rows.Next() {
+		s := LeaderboardEntry{}
+		err := rows.Scan(&s.Id, &s.UserId, &s.ChartId, &s.Score, &s.Timestamp, &s.Rank)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+
+	return res, nil
+}
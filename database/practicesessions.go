@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+type PracticeSessionRecord struct {
+	Id        int64
+	UserId    int64
+	StartedAt int64
+}
+
+type PracticeSessionsRepo struct {
+	conn *sql.Conn
+}
+
+func GetPracticeSessionsRepo(conn *sql.Conn) *PracticeSessionsRepo {
+	return &PracticeSessionsRepo{conn: conn}
+}
+
+func (repo *PracticeSessionsRepo) Start(ctx context.Context, userId int64, startedAt int64) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`insert into practice_sessions (user_id, started_at) values (?, ?)`,
+		userId, startedAt,
+	)
+}
+
+func (repo *PracticeSessionsRepo) End(ctx context.Context, id int64, endedAt int64) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`update practice_sessions set ended_at = ? where id = ?`,
+		endedAt, id,
+	)
+}
+
+// Returns the practice session of the user that has not ended yet. A user has at most one open session.
+func (repo *PracticeSessionsRepo) GetOpenByUser(ctx context.Context, userId int64) ([]PracticeSessionRecord, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		`select id, user_id, started_at from practice_sessions where user_id = ? and ended_at is null order by id desc limit 1`,
+		userId,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]PracticeSessionRecord, 0)
+
+	for rows.Next() {
+		s := PracticeSessionRecord{}
+		err := rows.Scan(&s.Id, &s.UserId, &s.StartedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+
+	return res, nil
+}
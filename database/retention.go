@@ -0,0 +1,79 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RetentionKind int
+
+const (
+	// keeps every score.
+	RetainAll RetentionKind = iota
+	// keeps the N most recently saved scores of each chart.
+	RetainRecent
+	// keeps the scores of each chart saved within the last N days.
+	RetainDays
+)
+
+// Describes which scores are kept for each chart of a user. The best score of a chart is always kept regardless of
+// the policy.
+type RetentionPolicy struct {
+	Kind  RetentionKind
+	Value int
+}
+
+var defaultRetentionPolicy = RetentionPolicy{Kind: RetainRecent, Value: 30}
+
+// Parses a retention policy in one of the formats "unlimited", "recent:N" or "days:N".
+func ParseRetentionPolicy(s string) (RetentionPolicy, error) {
+	if s == "unlimited" {
+		return RetentionPolicy{Kind: RetainAll}, nil
+	}
+
+	kind, valueStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return RetentionPolicy{}, fmt.Errorf("invalid retention policy %s, expecting unlimited, recent:N or days:N", s)
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil || value <= 0 {
+		return RetentionPolicy{}, fmt.Errorf("invalid value %s in retention policy %s, expecting a positive integer", valueStr, s)
+	}
+
+	switch kind {
+	case "recent":
+		return RetentionPolicy{Kind: RetainRecent, Value: value}, nil
+	case "days":
+		return RetentionPolicy{Kind: RetainDays, Value: value}, nil
+	default:
+		return RetentionPolicy{}, fmt.Errorf("invalid retention policy %s, expecting unlimited, recent:N or days:N", s)
+	}
+}
+
+func (p RetentionPolicy) String() string {
+	switch p.Kind {
+	case RetainRecent:
+		return fmt.Sprintf("recent:%v", p.Value)
+	case RetainDays:
+		return fmt.Sprintf("days:%v", p.Value)
+	default:
+		return "unlimited"
+	}
+}
+
+// Returns the arguments for the retention delete query. Scores are deleted only if they are both outside the most
+// recent limit and saved before the cutoff, so each policy disables the condition it does not use.
+func (p RetentionPolicy) queryArgs(now time.Time) (int, int64) {
+	switch p.Kind {
+	case RetainRecent:
+		return p.Value, math.MaxInt64
+	case RetainDays:
+		return 0, now.AddDate(0, 0, -p.Value).UnixMilli()
+	default:
+		return math.MaxInt, 0
+	}
+}
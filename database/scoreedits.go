@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+type ScoreEditRecord struct {
+	Id           int64
+	ScoreId      int64
+	UserId       int64
+	OldScore     int
+	NewScore     int
+	OldTimestamp int64
+	NewTimestamp int64
+	EditedAt     int64
+}
+
+type ScoreEditsRepo struct {
+	conn *sql.Conn
+}
+
+func GetScoreEditsRepo(conn *sql.Conn) *ScoreEditsRepo {
+	return &ScoreEditsRepo{conn: conn}
+}
+
+func (repo *ScoreEditsRepo) Insert(ctx context.Context, edit ScoreEditRecord) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`insert into score_edits (score_id, user_id, old_score, new_score, old_timestamp, new_timestamp, edited_at) values (?, ?, ?, ?, ?, ?, ?)`,
+		edit.ScoreId, edit.UserId, edit.OldScore, edit.NewScore, edit.OldTimestamp, edit.NewTimestamp, edit.EditedAt,
+	)
+}
+
+func (repo *ScoreEditsRepo) GetByUserAndChart(ctx context.Context, userId int64, chartId int, limit int) ([]ScoreEditRecord, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		`select
+			score_edits.id,
+			score_edits.score_id,
+			score_edits.user_id,
+			score_edits.old_score,
+			score_edits.new_score,
+			score_edits.old_timestamp,
+			score_edits.new_timestamp,
+			score_edits.edited_at
+		from
+			score_edits
+		inner join scores on
+			score_edits.score_id = scores.id
+		where
+			scores.user_id = ?
+			and scores.chart_id = ?
+			and scores.deleted_at is null
+		order by
+			score_edits.edited_at desc
+		limit ?`,
+		userId, chartId, limit,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanToScoreEdits(rows)
+}
+
+func scanToScoreEdits(rows *sql.Rows) ([]ScoreEditRecord, error) {
+	res := make([]ScoreEditRecord, 0)
+
+	for rows.Next() {
+		s := ScoreEditRecord{}
+		err := rows.Scan(&s.Id, &s.ScoreId, &s.UserId, &s.OldScore, &s.NewScore, &s.OldTimestamp, &s.NewTimestamp, &s.EditedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+
+	return res, nil
+}
@@ -3,6 +3,10 @@ package database
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 )
 
 type ScoreRecord struct {
@@ -18,21 +22,29 @@ type ScoreRecordRating struct {
 	Rating float64
 }
 
+type TrashedScoreRecord struct {
+	ScoreRecord
+	DeletedAt int64
+}
+
 type ScoresRepo struct {
 	conn *sql.Conn
 }
 
+// the play rating of a score, expecting the score as best.score and the chart constant as charts.cc.
+const SCORE_RATING_EXPR string = `case 
+			when best.score < 9800000 then max(charts.cc + (cast(best.score as float)-9500000)/ 300000, 0)
+			when best.score < 10000000 then charts.cc + 1 + (cast(best.score as float)-9800000)/ 200000
+			when best.score >= 10000000 then charts.cc + 2
+		end`
+
 const SCORE_RATING_QUERY string = `select
 		best.id,
 		best.user_id,
 		best.chart_id,
 		best.score,
 		best.timestamp,
-		case 
-			when best.score < 9800000 then max(charts.cc + (cast(best.score as float)-9500000)/ 300000, 0)
-			when best.score < 10000000 then charts.cc + 1 + (cast(best.score as float)-9800000)/ 200000
-			when best.score >= 10000000 then charts.cc + 2
-		end rating
+		` + SCORE_RATING_EXPR + ` rating
 	from
 		(
 		select
@@ -48,6 +60,8 @@ const SCORE_RATING_QUERY string = `select
 			scores
 		where
 			user_id = ?
+			and deleted_at is null
+			and timestamp < ?
 	) best
 	inner join charts on
 		best.chart_id = charts.id
@@ -58,22 +72,54 @@ const SCORE_RATING_QUERY string = `select
 	limit ?
 	offset ?`
 
+// deletes the scores that fall outside a retention policy. the best score of each chart is never deleted, with ties
+// broken by the earliest timestamp and then the lowest id so that exactly one score is kept as the best. the first
+// placeholder filters the scores to enforce on, the second exempts scores from being deleted.
+const RETENTION_DELETE_QUERY string = `delete from scores
+	where id in (
+		select
+			id
+		from
+			(
+			select
+				id,
+				timestamp,
+				row_number() over (partition by user_id, chart_id
+			order by
+				timestamp desc, id desc) recent_order,
+				row_number() over (partition by user_id, chart_id
+			order by
+				score desc, timestamp asc, id asc) best_order
+			from
+				scores
+			where
+				deleted_at is null
+				%s
+		)
+		where
+			best_order > 1
+			and recent_order > ?
+			and timestamp < ?
+			%s
+	)`
+
 func GetScoresRepo(conn *sql.Conn) *ScoresRepo {
 	return &ScoresRepo{conn: conn}
 }
 
-func (repo *ScoresRepo) Insert(ctx context.Context, userId int64, chartId int, score int, timestamp int64) (sql.Result, error) {
+// Inserts a score. The score is tagged with the practice session if practiceSessionId is not 0.
+func (repo *ScoresRepo) Insert(ctx context.Context, userId int64, chartId int, score int, timestamp int64, practiceSessionId int64) (sql.Result, error) {
 	return repo.conn.ExecContext(
 		ctx,
-		`insert into scores (user_id, chart_id, score, timestamp) values (?, ?, ?, ?)`,
-		userId, chartId, score, timestamp,
+		`insert into scores (user_id, chart_id, score, timestamp, practice_session_id) values (?, ?, ?, ?, nullif(?, 0))`,
+		userId, chartId, score, timestamp, practiceSessionId,
 	)
 }
 
 func (repo *ScoresRepo) GetById(ctx context.Context, id int64) ([]ScoreRecord, error) {
 	rows, err := repo.conn.QueryContext(
 		ctx,
-		`select id, user_id, chart_id, score, timestamp from scores where id = ?`,
+		`select id, user_id, chart_id, score, timestamp from scores where id = ? and deleted_at is null`,
 		id,
 	)
 
@@ -87,7 +133,7 @@ func (repo *ScoresRepo) GetById(ctx context.Context, id int64) ([]ScoreRecord, e
 func (repo *ScoresRepo) GetByUserAndChart(ctx context.Context, userId int64, chartId int) ([]ScoreRecord, error) {
 	rows, err := repo.conn.QueryContext(
 		ctx,
-		`select id, user_id, chart_id, score, timestamp from scores where user_id = ? and chart_id = ?`,
+		`select id, user_id, chart_id, score, timestamp from scores where user_id = ? and chart_id = ? and deleted_at is null`,
 		userId, chartId,
 	)
 
@@ -98,10 +144,38 @@ func (repo *ScoresRepo) GetByUserAndChart(ctx context.Context, userId int64, cha
 	return scanToScores(rows)
 }
 
+func (repo *ScoresRepo) GetByUser(ctx context.Context, userId int64) ([]ScoreRecord, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		`select id, user_id, chart_id, score, timestamp from scores where user_id = ? and deleted_at is null order by timestamp, id`,
+		userId,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanToScores(rows)
+}
+
+func (repo *ScoresRepo) GetByPracticeSession(ctx context.Context, practiceSessionId int64) ([]ScoreRecord, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		`select id, user_id, chart_id, score, timestamp from scores where practice_session_id = ? and deleted_at is null order by timestamp, id`,
+		practiceSessionId,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanToScores(rows)
+}
+
 func (repo *ScoresRepo) GetByUserAndChartWithOffset(ctx context.Context, userId int64, chartId int, offset int, limit int) ([]ScoreRecord, error) {
 	rows, err := repo.conn.QueryContext(
 		ctx,
-		`select id, user_id, chart_id, score, timestamp from scores where user_id = ? and chart_id = ? order by timestamp desc limit ? offset ?`,
+		`select id, user_id, chart_id, score, timestamp from scores where user_id = ? and chart_id = ? and deleted_at is null order by timestamp desc limit ? offset ?`,
 		userId, chartId, limit, offset,
 	)
 
@@ -116,7 +190,7 @@ func (repo *ScoresRepo) GetByUserAndChartWithOffset(ctx context.Context, userId
 func (repo *ScoresRepo) GetScoreCountByUserAndChart(ctx context.Context, userId int64, chartId int) (int, error) {
 	row, err := repo.conn.QueryContext(
 		ctx,
-		`select count(1) from scores where user_id = ? and chart_id = ?`,
+		`select count(1) from scores where user_id = ? and chart_id = ? and deleted_at is null`,
 		userId, chartId,
 	)
 
@@ -130,10 +204,12 @@ func (repo *ScoresRepo) GetScoreCountByUserAndChart(ctx context.Context, userId
 	return count, nil
 }
 
+// Returns the best score of a user on a chart, with ties broken by the earliest timestamp. The returned record has an
+// Id of 0 if the user has no scores saved for the chart.
 func (repo *ScoresRepo) GetBestScoreByUserAndChart(ctx context.Context, userId int64, chartId int) (ScoreRecord, error) {
 	row, err := repo.conn.QueryContext(
 		ctx,
-		`select id, user_id, chart_id, score, timestamp from scores where user_id = ? and chart_id = ? order by score desc limit 1`,
+		`select id, user_id, chart_id, score, timestamp from scores where user_id = ? and chart_id = ? and deleted_at is null order by score desc, timestamp asc, id asc limit 1`,
 		userId, chartId,
 	)
 
@@ -142,14 +218,23 @@ func (repo *ScoresRepo) GetBestScoreByUserAndChart(ctx context.Context, userId i
 	}
 
 	res, err := scanToScores(row)
-	return res[0], err
+	if err != nil || len(res) == 0 {
+		return ScoreRecord{}, err
+	}
+
+	return res[0], nil
 }
 
 func (repo *ScoresRepo) GetBestScoresByUserWithOffset(ctx context.Context, userId int64, offset int, limit int) ([]ScoreRecordRating, error) {
+	return repo.GetBestScoresByUserWithOffsetBefore(ctx, userId, math.MaxInt64, offset, limit)
+}
+
+// Same as GetBestScoresByUserWithOffset, but only considers the scores saved before the given timestamp.
+func (repo *ScoresRepo) GetBestScoresByUserWithOffsetBefore(ctx context.Context, userId int64, before int64, offset int, limit int) ([]ScoreRecordRating, error) {
 	rows, err := repo.conn.QueryContext(
 		ctx,
 		SCORE_RATING_QUERY,
-		userId, limit, offset,
+		userId, before, limit, offset,
 	)
 
 	if err != nil {
@@ -160,10 +245,15 @@ func (repo *ScoresRepo) GetBestScoresByUserWithOffset(ctx context.Context, userI
 }
 
 func (repo *ScoresRepo) GetBestScoreRatingsAverage(ctx context.Context, userId int64, limit int) (float64, float64, error) {
+	return repo.GetBestScoreRatingsAverageBefore(ctx, userId, math.MaxInt64, limit)
+}
+
+// Same as GetBestScoreRatingsAverage, but only considers the scores saved before the given timestamp.
+func (repo *ScoresRepo) GetBestScoreRatingsAverageBefore(ctx context.Context, userId int64, before int64, limit int) (float64, float64, error) {
 	res, err := repo.conn.QueryContext(
 		ctx,
 		`select avg(rating), avg(score) from (`+SCORE_RATING_QUERY+`)`,
-		userId, limit, 0,
+		userId, before, limit, 0,
 	)
 
 	if err != nil {
@@ -179,10 +269,15 @@ func (repo *ScoresRepo) GetBestScoreRatingsAverage(ctx context.Context, userId i
 }
 
 func (repo *ScoresRepo) GetUserPlayedChartCount(ctx context.Context, userId int64) (int, error) {
+	return repo.GetUserPlayedChartCountBefore(ctx, userId, math.MaxInt64)
+}
+
+// Same as GetUserPlayedChartCount, but only considers the scores saved before the given timestamp.
+func (repo *ScoresRepo) GetUserPlayedChartCountBefore(ctx context.Context, userId int64, before int64) (int, error) {
 	res, err := repo.conn.QueryContext(
 		ctx,
-		`select count(distinct chart_id) from scores where user_id = ?`,
-		userId,
+		`select count(distinct chart_id) from scores where user_id = ? and deleted_at is null and timestamp < ?`,
+		userId, before,
 	)
 
 	if err != nil {
@@ -196,6 +291,137 @@ func (repo *ScoresRepo) GetUserPlayedChartCount(ctx context.Context, userId int6
 	return count, nil
 }
 
+func (repo *ScoresRepo) Update(ctx context.Context, id int64, score int, timestamp int64) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`update scores set score = ?, timestamp = ? where id = ?`,
+		score, timestamp, id,
+	)
+}
+
+func (repo *ScoresRepo) GetTrashedById(ctx context.Context, id int64) ([]ScoreRecord, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		`select id, user_id, chart_id, score, timestamp from scores where id = ? and deleted_at is not null`,
+		id,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanToScores(rows)
+}
+
+func (repo *ScoresRepo) GetTrashedByUserWithOffset(ctx context.Context, userId int64, offset int, limit int) ([]TrashedScoreRecord, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		`select id, user_id, chart_id, score, timestamp, deleted_at from scores where user_id = ? and deleted_at is not null order by deleted_at desc, id desc limit ? offset ?`,
+		userId, limit, offset,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scanToTrashedScores(rows)
+}
+
+func (repo *ScoresRepo) GetTrashedCountByUser(ctx context.Context, userId int64) (int, error) {
+	row, err := repo.conn.QueryContext(
+		ctx,
+		`select count(1) from scores where user_id = ? and deleted_at is not null`,
+		userId,
+	)
+
+	if err != nil {
+		return -1, err
+	}
+
+	var count int
+	row.Next()
+	row.Scan(&count)
+	return count, nil
+}
+
+func (repo *ScoresRepo) Trash(ctx context.Context, id int64, timestamp int64) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`update scores set deleted_at = ? where id = ? and deleted_at is null`,
+		timestamp, id,
+	)
+}
+
+func (repo *ScoresRepo) Restore(ctx context.Context, id int64) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`update scores set deleted_at = null where id = ? and deleted_at is not null`,
+		id,
+	)
+}
+
+// Permanently deletes scores that were trashed before the given timestamp.
+func (repo *ScoresRepo) PurgeTrashed(ctx context.Context, before int64) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`delete from scores where deleted_at is not null and deleted_at < ?`,
+		before,
+	)
+}
+
+// Deletes the scores of a chart that fall outside the retention policy. This is done in a single statement, so the
+// policy is enforced atomically even if it is not called within a transaction. Scores with the given keepIds are never
+// deleted, so that a backdated score is not removed by the same save that inserted it.
+func (repo *ScoresRepo) EnforceRetention(ctx context.Context, userId int64, chartId int, policy RetentionPolicy, now time.Time, keepIds ...int64) (int64, error) {
+	if policy.Kind == RetainAll {
+		return 0, nil
+	}
+
+	limit, cutoff := policy.queryArgs(now)
+
+	args := []any{userId, chartId, limit, cutoff}
+	keepFilter := ``
+	if len(keepIds) > 0 {
+		keepFilter = fmt.Sprintf(`and id not in (%s)`, strings.TrimSuffix(strings.Repeat("?,", len(keepIds)), ","))
+		for _, id := range keepIds {
+			args = append(args, id)
+		}
+	}
+
+	res, err := repo.conn.ExecContext(
+		ctx,
+		fmt.Sprintf(RETENTION_DELETE_QUERY, `and user_id = ? and chart_id = ?`, keepFilter),
+		args...,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Same as EnforceRetention, but for the scores of every user and chart.
+func (repo *ScoresRepo) EnforceRetentionForAll(ctx context.Context, policy RetentionPolicy, now time.Time) (int64, error) {
+	if policy.Kind == RetainAll {
+		return 0, nil
+	}
+
+	limit, cutoff := policy.queryArgs(now)
+
+	res, err := repo.conn.ExecContext(
+		ctx,
+		fmt.Sprintf(RETENTION_DELETE_QUERY, ``, ``),
+		limit, cutoff,
+	)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
 func (repo *ScoresRepo) Delete(ctx context.Context, id int64) (sql.Result, error) {
 	return repo.conn.ExecContext(
 		ctx,
@@ -233,3 +459,18 @@ func scanToScoreRatings(rows *sql.Rows) ([]ScoreRecordRating, error) {
 
 	return res, nil
 }
+
+func scanToTrashedScores(rows *sql.Rows) ([]TrashedScoreRecord, error) {
+	res := make([]TrashedScoreRecord, 0)
+
+	for rows.Next() {
+		s := TrashedScoreRecord{}
+		err := rows.Scan(&s.Id, &s.UserId, &s.ChartId, &s.Score, &s.Timestamp, &s.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestScoresRepo(t *testing.T) *ScoresRepo {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s", filepath.Join(t.TempDir(), "kagura.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	err = setupDb(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return GetScoresRepo(conn)
+}
+
+func insertTestScore(t *testing.T, repo *ScoresRepo, score int, timestamp time.Time) int64 {
+	t.Helper()
+
+	res, err := repo.Insert(context.Background(), 1, 1, score, timestamp.UnixMilli(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _ := res.LastInsertId()
+	return id
+}
+
+func TestEnforceRetentionKeepsBackdatedScore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	policies := []RetentionPolicy{
+		{Kind: RetainRecent, Value: 1},
+		{Kind: RetainDays, Value: 30},
+	}
+
+	for _, policy := range policies {
+		t.Run(policy.String(), func(t *testing.T) {
+			repo := newTestScoresRepo(t)
+
+			insertTestScore(t, repo, 9900000, now.AddDate(0, 0, -1))
+			backdatedId := insertTestScore(t, repo, 9500000, now.AddDate(0, 0, -60))
+
+			_, err := repo.EnforceRetention(ctx, 1, 1, policy, now, backdatedId)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scores, err := repo.GetById(ctx, backdatedId)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(scores) != 1 {
+				t.Errorf("backdated score %v was deleted by the retention policy %s", backdatedId, policy)
+			}
+
+			_, err = repo.EnforceRetention(ctx, 1, 1, policy, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scores, err = repo.GetById(ctx, backdatedId)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(scores) != 0 {
+				t.Errorf("backdated score %v was kept by the retention policy %s without being exempted", backdatedId, policy)
+			}
+		})
+	}
+}
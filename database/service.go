@@ -5,15 +5,21 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/lilacse/kagura/logger"
 	_ "github.com/ncruces/go-sqlite3/driver"
 )
 
 type Service struct {
-	db *sql.DB
+	db              *sql.DB
+	trashRetention  time.Duration
+	retentionPolicy RetentionPolicy
 }
 
+const defaultTrashRetentionDays = 7
+
 func NewService(ctx context.Context) (*Service, error) {
 	dbPath := os.Getenv("KAGURA_DBPATH")
 	if dbPath == "" {
@@ -21,6 +27,30 @@ func NewService(ctx context.Context) (*Service, error) {
 		dbPath = "kagura.db"
 	}
 
+	trashRetentionDays := defaultTrashRetentionDays
+	trashRetentionStr := os.Getenv("KAGURA_TRASH_RETENTION_DAYS")
+	if trashRetentionStr == "" {
+		logger.Info(ctx, fmt.Sprintf("environment variable KAGURA_TRASH_RETENTION_DAYS is not set, using default value %v", defaultTrashRetentionDays))
+	} else {
+		days, err := strconv.Atoi(trashRetentionStr)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("invalid value %s for KAGURA_TRASH_RETENTION_DAYS, expecting a non-negative integer", trashRetentionStr)
+		}
+		trashRetentionDays = days
+	}
+
+	retentionPolicy := defaultRetentionPolicy
+	retentionPolicyStr := os.Getenv("KAGURA_SCORE_RETENTION")
+	if retentionPolicyStr == "" {
+		logger.Info(ctx, fmt.Sprintf("environment variable KAGURA_SCORE_RETENTION is not set, using default value %s", defaultRetentionPolicy))
+	} else {
+		policy, err := ParseRetentionPolicy(retentionPolicyStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for KAGURA_SCORE_RETENTION: %v", err)
+		}
+		retentionPolicy = policy
+	}
+
 	uri := fmt.Sprintf("file:%s", dbPath)
 	logger.Info(ctx, fmt.Sprintf("opening database on %s", uri))
 	db, err := sql.Open("sqlite3", uri)
@@ -33,7 +63,11 @@ func NewService(ctx context.Context) (*Service, error) {
 		return nil, err
 	}
 
-	return &Service{db: db}, nil
+	return &Service{
+		db:              db,
+		trashRetention:  time.Duration(trashRetentionDays) * 24 * time.Hour,
+		retentionPolicy: retentionPolicy,
+	}, nil
 }
 
 func setupDb(db *sql.DB) error {
@@ -49,11 +83,41 @@ func setupDb(db *sql.DB) error {
 			user_id,
 			chart_id
 		)`,
+		`create table if not exists score_edits (
+			id integer primary key,
+			score_id integer,
+			user_id integer,
+			old_score integer,
+			new_score integer,
+			old_timestamp integer,
+			new_timestamp integer,
+			edited_at integer
+		)`,
+		`create index if not exists score_edits_idx on score_edits (
+			score_id
+		)`,
+		`create table if not exists user_settings (
+			user_id integer primary key,
+			privacy integer not null default 0
+		)`,
+		`create table if not exists guild_rankings (
+			guild_id integer,
+			user_id integer,
+			primary key (guild_id, user_id)
+		)`,
+		`create table if not exists practice_sessions (
+			id integer primary key,
+			user_id integer,
+			started_at integer,
+			ended_at integer
+		)`,
+		`create index if not exists practice_sessions_idx on practice_sessions (
+			user_id
+		)`,
 		`create table if not exists charts (
 			id integer primary key,
 			cc real
 		)`,
-		`delete from charts`,
 		`PRAGMA journal_mode=WAL`,
 		`PRAGMA synchronous=NORMAL`,
 	}
@@ -66,9 +130,69 @@ func setupDb(db *sql.DB) error {
 		}
 	}
 
+	// columns added after a table is first created are added here, as sqlite does not support "if not exists" for
+	// "alter table ... add column".
+	columns := []struct {
+		table      string
+		column     string
+		definition string
+	}{
+		{"scores", "deleted_at", "integer"},
+		{"scores", "practice_session_id", "integer"},
+		{"user_settings", "timezone", "text not null default 'UTC'"},
+	}
+
+	for _, c := range columns {
+		err := addColumnIfNotExists(db, c.table, c.column, c.definition)
+		if err != nil {
+			return fmt.Errorf("failed to setup database: %v", err)
+		}
+	}
+
+	postDdls := []string{
+		`create index if not exists scores_deleted_idx on scores (
+			deleted_at
+		)`,
+		`create index if not exists scores_practice_session_idx on scores (
+			practice_session_id
+		)`,
+	}
+
+	for _, ddl := range postDdls {
+		_, err := db.Exec(ddl)
+
+		if err != nil {
+			return fmt.Errorf("failed to setup database: %v", err)
+		}
+	}
+
 	return nil
 }
 
+func addColumnIfNotExists(db *sql.DB, table string, column string, definition string) error {
+	rows, err := db.Query(fmt.Sprintf(`select name from pragma_table_info('%s')`, table))
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return err
+		}
+
+		if name == column {
+			return nil
+		}
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`alter table %s add column %s %s`, table, column, definition))
+	return err
+}
+
 func (svc *Service) NewSession(ctx context.Context) (*Session, error) {
 	conn, err := svc.db.Conn(ctx)
 	if err != nil {
@@ -78,6 +202,76 @@ func (svc *Service) NewSession(ctx context.Context) (*Session, error) {
 	return &Session{Conn: conn}, nil
 }
 
+func (svc *Service) TrashRetention() time.Duration {
+	return svc.trashRetention
+}
+
+func (svc *Service) RetentionPolicy() RetentionPolicy {
+	return svc.retentionPolicy
+}
+
+// Deletes every score that falls outside the configured retention policy, e.g. after the policy was tightened or for
+// scores saved before it was enforced.
+func (svc *Service) EnforceRetention(ctx context.Context) (int64, error) {
+	sess, err := svc.NewSession(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer sess.Conn.Close()
+
+	count, err := sess.GetScoresRepo().EnforceRetentionForAll(ctx, svc.retentionPolicy, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to enforce retention policy %s: %v", svc.retentionPolicy, err)
+	}
+
+	return count, nil
+}
+
+// Permanently deletes trashed scores once they are past the retention period. Runs until the context is cancelled.
+func (svc *Service) RunTrashPurger(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		svc.purgeTrash(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (svc *Service) purgeTrash(ctx context.Context) {
+	sess, err := svc.NewSession(ctx)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to purge trashed scores: %v", err))
+		return
+	}
+
+	defer func() {
+		err := sess.Conn.Close()
+		if err != nil {
+			logger.Error(ctx, fmt.Sprintf("failed to close session after purging trashed scores: %v", err))
+		}
+	}()
+
+	before := time.Now().Add(-svc.trashRetention).UnixMilli()
+
+	res, err := sess.GetScoresRepo().PurgeTrashed(ctx, before)
+	if err != nil {
+		logger.Error(ctx, fmt.Sprintf("failed to purge trashed scores: %v", err))
+		return
+	}
+
+	count, _ := res.RowsAffected()
+	if count > 0 {
+		logger.Info(ctx, fmt.Sprintf("purged %v trashed scores", count))
+	}
+}
+
 func (svc *Service) Close() error {
 	err := svc.db.Close()
 	if err != nil {
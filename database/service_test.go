@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/lilacse/kagura/dataservices/songdata"
+)
+
+// Checks that setting up an existing database, as maintenance tasks do, keeps the charts inserted by the bot.
+func TestSetupDbKeepsCharts(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s", filepath.Join(t.TempDir(), "kagura.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	err = setupDb(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	songs := []songdata.Song{{Charts: []songdata.Chart{{Id: 1, CC: 8.5}, {Id: 2, CC: 10.9}}}}
+	err = GetChartsRepo(conn).InsertCharts(ctx, songs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = setupDb(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	err = conn.QueryRowContext(ctx, `select count(1) from charts`).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 2 {
+		t.Errorf("charts table has %v charts after setting up the database again, expecting 2", count)
+	}
+}
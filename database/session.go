@@ -15,3 +15,23 @@ func (sess *Session) GetScoresRepo() *ScoresRepo {
 func (sess *Session) GetChartsRepo() *ChartsRepo {
 	return GetChartsRepo(sess.Conn)
 }
+
+func (sess *Session) GetScoreEditsRepo() *ScoreEditsRepo {
+	return GetScoreEditsRepo(sess.Conn)
+}
+
+func (sess *Session) GetUserSettingsRepo() *UserSettingsRepo {
+	return GetUserSettingsRepo(sess.Conn)
+}
+
+func (sess *Session) GetGuildRankingsRepo() *GuildRankingsRepo {
+	return GetGuildRankingsRepo(sess.Conn)
+}
+
+func (sess *Session) GetLeaderboardsRepo() *LeaderboardsRepo {
+	return GetLeaderboardsRepo(sess.Conn)
+}
+
+func (sess *Session) GetPracticeSessionsRepo() *PracticeSessionsRepo {
+	return GetPracticeSessionsRepo(sess.Conn)
+}
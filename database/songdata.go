@@ -15,7 +15,14 @@ func GetChartsRepo(conn *sql.Conn) *ChartsRepo {
 	return &ChartsRepo{conn: conn}
 }
 
+// Replaces the charts table with the charts of the given songs. This is done when the bot starts rather than when the
+// database is set up, so maintenance tasks that open the database do not leave the table empty.
 func (repo *ChartsRepo) InsertCharts(ctx context.Context, songs []songdata.Song) error {
+	_, err := repo.conn.ExecContext(ctx, `delete from charts`)
+	if err != nil {
+		return err
+	}
+
 	for _, s := range songs {
 		for _, c := range s.Charts {
 			_, err := repo.conn.ExecContext(
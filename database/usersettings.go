@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+type PrivacyLevel int
+
+const (
+	// scores are only visible to the user.
+	PrivacyPrivate PrivacyLevel = iota
+	// scores are visible to members of guilds the user is in.
+	PrivacyGuild
+	// scores are visible to everyone.
+	PrivacyPublic
+)
+
+type UserSettings struct {
+	UserId   int64
+	Privacy  PrivacyLevel
+	Timezone string
+}
+
+type UserSettingsRepo struct {
+	conn *sql.Conn
+}
+
+func GetUserSettingsRepo(conn *sql.Conn) *UserSettingsRepo {
+	return &UserSettingsRepo{conn: conn}
+}
+
+// Returns the settings of a user, or the default settings if the user has not changed any.
+func (repo *UserSettingsRepo) Get(ctx context.Context, userId int64) (UserSettings, error) {
+	rows, err := repo.conn.QueryContext(
+		ctx,
+		`select user_id, privacy, timezone from user_settings where user_id = ?`,
+		userId,
+	)
+
+	if err != nil {
+		return UserSettings{}, err
+	}
+
+	defer rows.Close()
+
+	settings := UserSettings{UserId: userId, Privacy: PrivacyPrivate, Timezone: "UTC"}
+	if rows.Next() {
+		err := rows.Scan(&settings.UserId, &settings.Privacy, &settings.Timezone)
+		if err != nil {
+			return UserSettings{}, err
+		}
+	}
+
+	return settings, nil
+}
+
+func (repo *UserSettingsRepo) SetPrivacy(ctx context.Context, userId int64, privacy PrivacyLevel) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`insert into user_settings (user_id, privacy) values (?, ?) on conflict (user_id) do update set privacy = excluded.privacy`,
+		userId, privacy,
+	)
+}
+
+// Sets the timezone of a user, expecting an IANA timezone name (e.g. Asia/Tokyo).
+func (repo *UserSettingsRepo) SetTimezone(ctx context.Context, userId int64, timezone string) (sql.Result, error) {
+	return repo.conn.ExecContext(
+		ctx,
+		`insert into user_settings (user_id, timezone) values (?, ?) on conflict (user_id) do update set timezone = excluded.timezone`,
+		userId, timezone,
+	)
+}
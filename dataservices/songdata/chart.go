@@ -1,6 +1,9 @@
 package songdata
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+)
 
 type Chart struct {
 	Id    int     `json:"id"`
@@ -8,6 +11,8 @@ type Chart struct {
 	Level string  `json:"level"`
 	CC    float64 `json:"cc"`
 	Ver   string  `json:"ver"`
+	// the number of notes in the chart, 0 if it is unknown.
+	Notes int `json:"notes,omitempty"`
 }
 
 func (c *Chart) GetDiffDisplayName() string {
@@ -53,6 +58,27 @@ func (c *Chart) GetActualScoreRating(score int) float64 {
 	return ptt
 }
 
+// Returns the minimum score that gives at least the given rating. Returns false if the rating is higher than the
+// rating of a Pure Memory, or if the chart constant is unknown.
+func (c *Chart) GetMinScoreForRating(rating float64) (int, bool) {
+	if rating <= 0.0 {
+		return 0, true
+	}
+
+	if c.CC == 0.0 || rating > c.CC+2.0 {
+		return 0, false
+	}
+
+	var score float64
+	if rating >= c.CC+1.0 {
+		score = 9800000 + (rating-c.CC-1.0)*200000
+	} else {
+		score = 9500000 + (rating-c.CC)*300000
+	}
+
+	return max(0, int(math.Ceil(score))), true
+}
+
 func (c *Chart) GetScoreRatingString(score int) string {
 	if c.CC != 0.0 {
 		return fmt.Sprintf("%.4f", c.GetActualScoreRating(score))
@@ -68,3 +94,34 @@ func (c *Chart) GetCCString() string {
 		return "?"
 	}
 }
+
+// Returns the maximum score of the chart, which is 10,000,000 plus one for each note hit as a shiny Pure. Returns false
+// if the note count of the chart is unknown.
+func (c *Chart) GetMaxScore() (int, bool) {
+	if c.Notes <= 0 {
+		return 0, false
+	}
+
+	return 10000000 + c.Notes, true
+}
+
+// Returns whether some combination of judgments produces the score. Each Pure is worth 10,000,000 / notes and each
+// Far half of that, with the sum floored, plus one for each shiny Pure. Every score is considered achievable if the
+// note count of the chart is unknown.
+func (c *Chart) IsScoreAchievable(score int) bool {
+	if c.Notes <= 0 {
+		return true
+	}
+
+	// a Pure counts as 2 halves and a Far as 1, so h is the number of halves hit. any h from 0 to 2 * notes can be
+	// formed with at most floor(h / 2) Pures, each of which can be shiny.
+	for h := 0; h <= 2*c.Notes; h++ {
+		base := int(int64(h) * 5000000 / int64(c.Notes))
+		shiny := score - base
+		if shiny >= 0 && shiny <= h/2 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package songdata
+
+import "testing"
+
+func TestGetMaxScore(t *testing.T) {
+	cases := []struct {
+		notes int
+		max   int
+		ok    bool
+	}{
+		{0, 0, false},
+		{1, 10000001, true},
+		{3, 10000003, true},
+		{1450, 10001450, true},
+	}
+
+	for _, c := range cases {
+		chart := Chart{Notes: c.notes}
+		max, ok := chart.GetMaxScore()
+		if max != c.max || ok != c.ok {
+			t.Errorf("GetMaxScore() with %v notes = (%v, %v), expecting (%v, %v)", c.notes, max, ok, c.max, c.ok)
+		}
+	}
+}
+
+func TestIsScoreAchievable(t *testing.T) {
+	cases := []struct {
+		notes      int
+		score      int
+		achievable bool
+	}{
+		// every score is accepted if the note count is unknown.
+		{0, 10009999, true},
+
+		{3, 0, true},
+		{3, 10000000, true},
+		{3, 10000003, true},
+		{3, 10000004, false},
+		{3, 9999999, false},
+		// a Pure is worth 3333333.33..., floored to 3333333, plus one if it is shiny.
+		{3, 3333333, true},
+		{3, 3333334, true},
+		{3, 3333335, false},
+		// a Far is worth 1666666.66..., floored to 1666666, and is never shiny.
+		{3, 1666666, true},
+		{3, 1666667, false},
+		// 2 Pures and a Far are worth 8333333.33..., floored to 8333333, plus up to 2 shiny Pures.
+		{3, 8333333, true},
+		{3, 8333335, true},
+		{3, 8333336, false},
+
+		// 1449 Pures and a Far are worth 9996551.72..., floored to 9996551, plus up to 1449 shiny Pures.
+		{1450, 9996551, true},
+		{1450, 9998000, true},
+		{1450, 9998001, false},
+		{1450, 10000000, true},
+		{1450, 10001450, true},
+		{1450, 10001451, false},
+	}
+
+	for _, c := range cases {
+		chart := Chart{Notes: c.notes}
+		achievable := chart.IsScoreAchievable(c.score)
+		if achievable != c.achievable {
+			t.Errorf("IsScoreAchievable(%v) with %v notes = %v, expecting %v", c.score, c.notes, achievable, c.achievable)
+		}
+	}
+}
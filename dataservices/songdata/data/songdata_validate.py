@@ -35,6 +35,12 @@ additional validation for values:
 - cc must be a floating value
 - ver must be a semver compatible string
 
+every chart entry may contain the following optional keys:
+- notes
+
+additional validation for optional values:
+- notes must be a positive integer
+
 song id is expected to be sorted ascendingly by the following sorting criteria:
 - version in which the song is first added
 - title (converted to lowercase)
@@ -250,6 +256,14 @@ for song in data:
             )
             continue
 
+        if "notes" in c and (type(c["notes"]) != int or c["notes"] <= 0):
+            is_charts_valid = False
+            is_data_valid = False
+            errs.append(
+                f"unexpected note count ({c["notes"]}) found in chart entry for song '{song["title"]}':\n{json.dumps(c)}"
+            )
+            continue
+
         ver: str = c["ver"].split(".")
         is_ver_valid = True
 
@@ -0,0 +1,13 @@
+package songdata
+
+import "time"
+
+// Arcaea 1.0, the oldest version in songdata, was released on 9 March 2017. The bound is the start of that day in the
+// earliest timezone (UTC+14), so it holds wherever the player is. Songdata does not record when later versions were
+// released, so every chart shares this bound.
+var firstReleaseTime = time.Date(2017, 3, 9, 0, 0, 0, 0, time.FixedZone("UTC+14", 14*60*60))
+
+// Returns the earliest time the chart could have been played, which is the first release of the game.
+func (c *Chart) GetEarliestPlayTime() time.Time {
+	return firstReleaseTime
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/diamondburned/arikawa/v3/gateway"
 	"github.com/diamondburned/arikawa/v3/state"
 	"github.com/diamondburned/arikawa/v3/utils/json/option"
+	"github.com/lilacse/kagura/commands"
 	"github.com/lilacse/kagura/database"
 	"github.com/lilacse/kagura/dataservices"
 	"github.com/lilacse/kagura/embedbuilder"
@@ -19,13 +20,15 @@ type factory struct {
 	store    *store.Store
 	db       *database.Service
 	datasvcs *dataservices.Provider
+	registry *commands.Registry
 }
 
-func NewFactory(store *store.Store, db *database.Service, datasvcs *dataservices.Provider) *factory {
+func NewFactory(store *store.Store, db *database.Service, datasvcs *dataservices.Provider, registry *commands.Registry) *factory {
 	return &factory{
 		store:    store,
 		db:       db,
 		datasvcs: datasvcs,
+		registry: registry,
 	}
 }
 
@@ -42,6 +45,7 @@ func (f *factory) NewOnInteractionCreateHandler() *onInteractionCreateHandler {
 		store:    f.store,
 		db:       f.db,
 		datasvcs: f.datasvcs,
+		registry: f.registry,
 	}
 }
 
@@ -76,3 +80,30 @@ func sendCommandError(ctx context.Context, r any, st *state.State, e *gateway.In
 
 	st.RespondInteraction(e.InteractionEvent.ID, e.InteractionEvent.Token, d)
 }
+
+func sendCommandUserError(st *state.State, msg string, e *gateway.InteractionCreateEvent) {
+	d := api.InteractionResponse{
+		Type: api.MessageInteractionWithSource,
+		Data: &api.InteractionResponseData{
+			Embeds: &[]discord.Embed{
+				embedbuilder.UserError(msg),
+			},
+			AllowedMentions: &api.AllowedMentions{
+				RepliedUser: option.False,
+			},
+		},
+	}
+
+	st.RespondInteraction(e.InteractionEvent.ID, e.InteractionEvent.Token, d)
+}
+
+func sendEmptyAutocomplete(st *state.State, e *gateway.InteractionCreateEvent) {
+	d := api.InteractionResponse{
+		Type: api.AutocompleteResult,
+		Data: &api.InteractionResponseData{
+			Choices: api.AutocompleteStringChoices{},
+		},
+	}
+
+	st.RespondInteraction(e.InteractionEvent.ID, e.InteractionEvent.Token, d)
+}
@@ -1,14 +1,11 @@
 package handler
 
 import (
-	"context"
 	"fmt"
-	"runtime/debug"
 	"strings"
 
 	"github.com/diamondburned/arikawa/v3/discord"
 	"github.com/diamondburned/arikawa/v3/gateway"
-	"github.com/google/uuid"
 	"github.com/lilacse/kagura/commands"
 	"github.com/lilacse/kagura/database"
 	"github.com/lilacse/kagura/dataservices"
@@ -20,6 +17,7 @@ type onInteractionCreateHandler struct {
 	store    *store.Store
 	db       *database.Service
 	datasvcs *dataservices.Provider
+	registry *commands.Registry
 }
 
 type interactionType int
@@ -28,84 +26,65 @@ const (
 	componentInteraction interactionType = iota
 	commandInteraction
 	modalInteraction
+	autocompleteInteraction
 )
 
-type interactionHandler func(ctx context.Context, e *gateway.InteractionCreateEvent) bool
-
 func (h *onInteractionCreateHandler) Handle(e *gateway.InteractionCreateEvent) {
-	if e.Data.InteractionType() == discord.ComponentInteractionType {
-		switch e.Data.(type) {
-		case *discord.ButtonInteraction:
-			params := strings.Split(string(e.Data.(*discord.ButtonInteraction).CustomID), ",")
-			if params[0] == e.SenderID().String() {
-				handleInteraction(e, h, componentInteraction)
-			}
-		}
-	} else if e.Data.InteractionType() == discord.CommandInteractionType {
-		switch e.Data.(type) {
-		case *discord.CommandInteraction:
-			handleInteraction(e, h, commandInteraction)
-		}
-	} else if e.Data.InteractionType() == discord.ModalInteractionType {
-		switch e.Data.(type) {
-		case *discord.ModalInteraction:
-			handleInteraction(e, h, modalInteraction)
-		}
-	}
-}
+	ctx := h.store.Bot.Context()
 
-func handleInteraction(e *gateway.InteractionCreateEvent, h *onInteractionCreateHandler, t interactionType) {
-	traceId := uuid.NewString()
-	ctx := context.WithValue(h.store.Bot.Context(), logger.TraceId, traceId)
+	var route commands.Route
+	var ok bool
+	var t interactionType
+	var label string
 
-	componentHandlers := []interactionHandler{
-		commands.NewScoresHandler(h.store, h.db, h.datasvcs.SongData()).HandleScorePageSelect,
-		commands.NewB30Handler(h.store, h.db, h.datasvcs.SongData()).HandleB30PageSelect,
-		commands.NewSaveHandler(h.store, h.db, h.datasvcs.SongData()).HandleSaveAnother,
+	switch data := e.Data.(type) {
+	case *discord.ButtonInteraction:
+		t = componentInteraction
+		receiver := getReceiver(string(data.CustomID))
+		route, ok = h.registry.GetComponentRoute(receiver)
+		label = fmt.Sprintf("button %s", receiver)
+	case *discord.CommandInteraction:
+		t = commandInteraction
+		route, ok = h.registry.GetCommandRoute(data.Name)
+		label = fmt.Sprintf("command /%s", data.Name)
+	case *discord.ModalInteraction:
+		t = modalInteraction
+		receiver := getReceiver(string(data.CustomID))
+		route, ok = h.registry.GetModalRoute(receiver)
+		label = fmt.Sprintf("modal %s", receiver)
+	case *discord.AutocompleteInteraction:
+		t = autocompleteInteraction
+		route, ok = h.registry.GetAutocompleteRoute(data.Name)
+		label = fmt.Sprintf("autocomplete /%s", data.Name)
+	default:
+		return
 	}
 
-	commandHandlers := []interactionHandler{
-		commands.NewSongHandler(h.store, h.datasvcs.SongData()).HandleSlashCommand,
-		commands.NewStepHandler(h.store, h.datasvcs.SongData()).HandleSlashCommand,
-		commands.NewSaveHandler(h.store, h.db, h.datasvcs.SongData()).HandleSlashCommand,
-		commands.NewUnsaveHandler(h.store, h.db, h.datasvcs.SongData()).HandleSlashCommand,
-		commands.NewPttHandler(h.store, h.datasvcs.SongData()).HandleSlashCommand,
-		commands.NewRandomHandler(h.store, h.datasvcs.SongData()).HandleSlashCommand,
-		commands.NewB30Handler(h.store, h.db, h.datasvcs.SongData()).HandleSlashCommand,
-		commands.NewScoresHandler(h.store, h.db, h.datasvcs.SongData()).HandleSlashCommand,
+	if !ok {
+		logger.Warn(ctx, fmt.Sprintf("no handler is registered for %s", label))
+		return
 	}
 
-	modalHandlers := []interactionHandler{
-		commands.NewSaveHandler(h.store, h.db, h.datasvcs.SongData()).HandleSaveAnotherModalSubmit,
-	}
+	st := h.store.Bot.State()
 
-	defer func() {
-		r := recover()
-		if r != nil {
-			logger.Error(ctx, fmt.Sprintf("error handling interaction: %s\nstack trace: %s", r, debug.Stack()))
-			switch t {
-			case componentInteraction:
-				sendHandleError(ctx, r, h.store.Bot.State(), e.Message.ID, e.ChannelID)
-			case commandInteraction:
-				sendCommandError(ctx, r, h.store.Bot.State(), e)
-			}
-		}
-	}()
+	handler := chain(route.Handler,
+		withTracing(),
+		withTiming(label),
+		withRecovery(st, t),
+		withAuthorization(st, t, route.Command),
+		withRateLimit(h.store, t),
+		withSession(st, h.db, route.Command),
+	)
 
-	var hs []interactionHandler
-	switch t {
-	case componentInteraction:
-		hs = componentHandlers
-	case commandInteraction:
-		hs = commandHandlers
-	case modalInteraction:
-		hs = modalHandlers
-	}
+	handler(ctx, e)
+}
 
-	for _, h := range hs {
-		handled := h(ctx, e)
-		if handled {
-			return
-		}
+// Returns the receiver of a custom ID, which is in the format of "userId,receiver,params...".
+func getReceiver(customId string) string {
+	params := strings.Split(customId, ",")
+	if len(params) < 2 {
+		return ""
 	}
+
+	return params[1]
 }
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/diamondburned/arikawa/v3/discord"
+	"github.com/diamondburned/arikawa/v3/gateway"
+	"github.com/diamondburned/arikawa/v3/state"
+	"github.com/google/uuid"
+	"github.com/lilacse/kagura/commands"
+	"github.com/lilacse/kagura/database"
+	"github.com/lilacse/kagura/logger"
+	"github.com/lilacse/kagura/store"
+)
+
+type middleware func(next commands.InteractionHandler) commands.InteractionHandler
+
+// Wraps the handler with the middlewares, with the first middleware being the outermost.
+func chain(h commands.InteractionHandler, mws ...middleware) commands.InteractionHandler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
+
+// Gives the interaction a trace ID, which is included in every log written with its context.
+func withTracing() middleware {
+	return func(next commands.InteractionHandler) commands.InteractionHandler {
+		return func(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+			return next(context.WithValue(ctx, logger.TraceId, uuid.NewString()), e)
+		}
+	}
+}
+
+// Logs how long the interaction took to handle.
+func withTiming(label string) middleware {
+	return func(next commands.InteractionHandler) commands.InteractionHandler {
+		return func(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+			st := time.Now()
+			handled := next(ctx, e)
+			logger.Info(ctx, fmt.Sprintf("handled %s in %s", label, time.Since(st)))
+			return handled
+		}
+	}
+}
+
+// Recovers from panics in the handler, logging them and responding with the error in the way the interaction type
+// allows.
+func withRecovery(st *state.State, t interactionType) middleware {
+	return func(next commands.InteractionHandler) commands.InteractionHandler {
+		return func(ctx context.Context, e *gateway.InteractionCreateEvent) (handled bool) {
+			defer func() {
+				r := recover()
+				if r != nil {
+					logger.Error(ctx, fmt.Sprintf("error handling interaction: %s\nstack trace: %s", r, debug.Stack()))
+					switch t {
+					case componentInteraction:
+						sendHandleError(ctx, r, st, e.Message.ID, e.ChannelID)
+					case commandInteraction, modalInteraction:
+						sendCommandError(ctx, r, st, e)
+					case autocompleteInteraction:
+						sendEmptyAutocomplete(st, e)
+					}
+					handled = true
+				}
+			}()
+
+			return next(ctx, e)
+		}
+	}
+}
+
+// Ignores buttons pressed by anyone other than the user they were created for, and rejects commands that can only be
+// used in servers when they are used elsewhere.
+func withAuthorization(st *state.State, t interactionType, cmd commands.Command) middleware {
+	return func(next commands.InteractionHandler) commands.InteractionHandler {
+		return func(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+			switch t {
+			case componentInteraction:
+				params := strings.Split(string(e.Data.(*discord.ButtonInteraction).CustomID), ",")
+				if params[0] != e.SenderID().String() {
+					return true
+				}
+			case commandInteraction:
+				if cmd.GuildOnly && !e.GuildID.IsValid() {
+					sendCommandUserError(st, "This command is only available in servers!", e)
+					return true
+				}
+			}
+
+			return next(ctx, e)
+		}
+	}
+}
+
+// Rejects interactions of users who have reached the rate limit. Autocomplete is not limited, as it is triggered on
+// every keystroke.
+func withRateLimit(s *store.Store, t interactionType) middleware {
+	return func(next commands.InteractionHandler) commands.InteractionHandler {
+		return func(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+			if t != autocompleteInteraction && !s.RateLimits.Allow(int64(e.SenderID()), time.Now()) {
+				logger.Warn(ctx, fmt.Sprintf("rate limited user %v", e.SenderID()))
+				sendCommandUserError(s.Bot.State(), "You are doing that too fast! Please try again in a few seconds.", e)
+				return true
+			}
+
+			return next(ctx, e)
+		}
+	}
+}
+
+// Opens a database session for the handlers of commands that use the database, which they get from their context. The
+// session is closed once the handler returns.
+func withSession(st *state.State, db *database.Service, cmd commands.Command) middleware {
+	return func(next commands.InteractionHandler) commands.InteractionHandler {
+		if !cmd.UsesDatabase {
+			return next
+		}
+
+		return func(ctx context.Context, e *gateway.InteractionCreateEvent) bool {
+			sess, err := db.NewSession(ctx)
+			if err != nil {
+				logger.Error(ctx, fmt.Sprintf("failed to open database session: %s", err.Error()))
+				sendCommandError(ctx, err, st, e)
+				return true
+			}
+
+			defer func() {
+				err := sess.Conn.Close()
+				if err != nil {
+					logger.Error(ctx, fmt.Sprintf("failed to close database session: %s", err.Error()))
+				}
+			}()
+
+			return next(database.WithSession(ctx, sess), e)
+		}
+	}
+}
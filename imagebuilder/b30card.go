@@ -0,0 +1,125 @@
+package imagebuilder
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"time"
+)
+
+type B30CardEntry struct {
+	Title   string
+	DiffKey string
+	Level   string
+	CC      string
+	Score   int
+	Rating  float64
+	Badge   string
+}
+
+type B30Card struct {
+	Username  string
+	AvgRating float64
+	AvgScore  float64
+	Entries   []B30CardEntry
+}
+
+var (
+	tileColor  = color.RGBA{0x31, 0x33, 0x38, 0xff}
+	badgeColor = color.RGBA{0x1e, 0x1f, 0x22, 0xff}
+	diffColors = map[string]color.RGBA{
+		"pst": {0x3a, 0x9f, 0xd6, 0xff},
+		"prs": {0x8a, 0xc0, 0x43, 0xff},
+		"ftr": {0x8a, 0x4f, 0xa8, 0xff},
+		"etr": {0x6a, 0x5a, 0xcd, 0xff},
+		"byd": {0xc2, 0x24, 0x3e, 0xff},
+	}
+	badgeTextColors = map[string]color.RGBA{
+		"PM":  {0x7f, 0xe8, 0xff, 0xff},
+		"EX+": {0xff, 0xd7, 0x5e, 0xff},
+		"EX":  {0xff, 0xd7, 0x5e, 0xff},
+	}
+)
+
+const (
+	cardColumns   = 3
+	cardRows      = 10
+	cardPadding   = 30
+	cardHeaderH   = 130
+	cardFooterH   = 40
+	tileWidth     = 470
+	tileHeight    = 100
+	tileGap       = 15
+	tileStripeW   = 8
+	tileTextLeft  = 60
+	tileBadgeSize = 64
+)
+
+func RenderB30Card(card B30Card) ([]byte, error) {
+	width := cardPadding*2 + cardColumns*tileWidth + (cardColumns-1)*tileGap
+	height := cardHeaderH + cardRows*tileHeight + (cardRows-1)*tileGap + cardFooterH + cardPadding
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), bgColor)
+
+	drawTextClipped(img, cardPadding, cardPadding, fmt.Sprintf("%s - Best 30", card.Username), textColor, 3, width-cardPadding*2)
+	summary := fmt.Sprintf("Average rating: %.4f    Average score: %.2f", card.AvgRating, card.AvgScore)
+	drawText(img, cardPadding, cardPadding+glyphHeight*3+16, summary, mutedColor, 2)
+
+	for i, entry := range card.Entries {
+		if i >= cardColumns*cardRows {
+			break
+		}
+
+		// entries are laid out column by column, so each column reads as a continuous ranking.
+		col := i / cardRows
+		row := i % cardRows
+
+		x := cardPadding + col*(tileWidth+tileGap)
+		y := cardHeaderH + row*(tileHeight+tileGap)
+
+		drawB30Tile(img, x, y, i+1, entry)
+	}
+
+	footer := fmt.Sprintf("kagura · generated %s UTC", time.Now().UTC().Format("2006-01-02 15:04"))
+	drawText(img, width-cardPadding-measureText(footer, 1), height-cardPadding, footer, mutedColor, 1)
+
+	return encodePng(img)
+}
+
+func drawB30Tile(img *image.RGBA, x int, y int, rank int, entry B30CardEntry) {
+	fillRect(img, image.Rect(x, y, x+tileWidth, y+tileHeight), tileColor)
+
+	diffColor, ok := diffColors[entry.DiffKey]
+	if !ok {
+		diffColor = color.RGBA{0x80, 0x80, 0x80, 0xff}
+	}
+	fillRect(img, image.Rect(x, y, x+tileStripeW, y+tileHeight), diffColor)
+
+	rankStr := fmt.Sprintf("#%d", rank)
+	drawText(img, x+tileStripeW+8, y+10, rankStr, mutedColor, 1)
+
+	textX := x + tileTextLeft
+	textW := tileWidth - tileTextLeft - tileBadgeSize - 20
+
+	drawTextClipped(img, textX, y+8, entry.Title, textColor, 2, textW)
+
+	chartStr := fmt.Sprintf("%s Lv%s (%s)", strings.ToUpper(entry.DiffKey), entry.Level, entry.CC)
+	drawText(img, textX, y+40, chartStr, diffColor, 1)
+
+	resultStr := fmt.Sprintf("%d  ▸  %.4f", entry.Score, entry.Rating)
+	drawText(img, textX, y+62, resultStr, textColor, 2)
+
+	badgeX := x + tileWidth - tileBadgeSize - 12
+	badgeY := y + (tileHeight-tileBadgeSize)/2
+	fillRect(img, image.Rect(badgeX, badgeY, badgeX+tileBadgeSize, badgeY+tileBadgeSize), badgeColor)
+
+	badgeText, ok := badgeTextColors[entry.Badge]
+	if !ok {
+		badgeText = textColor
+	}
+	badgeTextX := badgeX + (tileBadgeSize-measureText(entry.Badge, 2))/2
+	badgeTextY := badgeY + (tileBadgeSize-glyphHeight*2)/2
+	drawText(img, badgeTextX, badgeTextY, entry.Badge, badgeText, 2)
+}
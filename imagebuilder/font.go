@@ -0,0 +1,72 @@
+package imagebuilder
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/hajimehoshi/bitmapfont/v3"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// the bitmap font is embedded into the binary and covers CJK characters, so no font files or network fetches are
+// needed at runtime. glyphs are 12px high and scaled up with nearest neighbour sampling to keep them crisp.
+var face font.Face = bitmapfont.FaceEA
+
+const glyphHeight = 12
+
+func measureText(text string, scale int) int {
+	return font.MeasureString(face, text).Ceil() * scale
+}
+
+// Draws text with its top-left corner at (x, y).
+func drawText(dst draw.Image, x int, y int, text string, c color.Color, scale int) {
+	w := font.MeasureString(face, text).Ceil()
+	if w == 0 {
+		return
+	}
+
+	ascent := face.Metrics().Ascent.Ceil()
+	src := image.NewAlpha(image.Rect(0, 0, w, glyphHeight))
+
+	d := font.Drawer{
+		Dst:  src,
+		Src:  image.Opaque,
+		Face: face,
+		Dot:  fixed.P(0, ascent),
+	}
+	d.DrawString(text)
+
+	fill := image.NewUniform(c)
+
+	for sy := 0; sy < glyphHeight; sy++ {
+		for sx := 0; sx < w; sx++ {
+			a := src.AlphaAt(sx, sy).A
+			if a == 0 {
+				continue
+			}
+
+			r := image.Rect(x+sx*scale, y+sy*scale, x+(sx+1)*scale, y+(sy+1)*scale)
+			draw.DrawMask(dst, r, fill, image.Point{}, image.NewUniform(color.Alpha{A: a}), image.Point{}, draw.Over)
+		}
+	}
+}
+
+// Draws text truncated with an ellipsis so that it fits within maxWidth.
+func drawTextClipped(dst draw.Image, x int, y int, text string, c color.Color, scale int, maxWidth int) {
+	if measureText(text, scale) <= maxWidth {
+		drawText(dst, x, y, text, c, scale)
+		return
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		t := string(runes) + "…"
+		if measureText(t, scale) <= maxWidth {
+			drawText(dst, x, y, t, c, scale)
+			return
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package imagebuilder
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"time"
+)
+
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+type Series struct {
+	Name   string
+	Color  color.RGBA
+	Points []Point
+}
+
+type LineChart struct {
+	Title  string
+	Series []Series
+	// format used for the labels on the value axis, e.g. "%.2f"
+	ValueFormat string
+	// location the dates on the time axis are labelled in, UTC if nil.
+	Location *time.Location
+}
+
+var (
+	bgColor        = color.RGBA{0x2b, 0x2d, 0x31, 0xff}
+	gridColor      = color.RGBA{0x3f, 0x41, 0x47, 0xff}
+	axisColor      = color.RGBA{0x8a, 0x8e, 0x96, 0xff}
+	textColor      = color.RGBA{0xdb, 0xde, 0xe1, 0xff}
+	mutedColor     = color.RGBA{0x9a, 0x9e, 0xa6, 0xff}
+	PrimaryColor   = color.RGBA{0x33, 0x99, 0xff, 0xff}
+	SecondaryColor = color.RGBA{0xff, 0xb0, 0x40, 0xff}
+)
+
+const (
+	chartWidth   = 1000
+	chartHeight  = 500
+	marginLeft   = 100
+	marginRight  = 30
+	marginTop    = 70
+	marginBottom = 50
+	yTickCount   = 5
+	xTickCount   = 4
+)
+
+func RenderLineChart(chart LineChart) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	fillRect(img, img.Bounds(), bgColor)
+
+	drawText(img, marginLeft, 20, chart.Title, textColor, 2)
+
+	minT, maxT, minV, maxV, ok := getBounds(chart.Series)
+	if !ok {
+		drawText(img, marginLeft, chartHeight/2, "No data in range", mutedColor, 2)
+		return encodePng(img)
+	}
+
+	plot := image.Rect(marginLeft, marginTop, chartWidth-marginRight, chartHeight-marginBottom)
+
+	toX := func(t time.Time) int {
+		ratio := float64(t.Sub(minT)) / float64(maxT.Sub(minT))
+		return plot.Min.X + int(math.Round(ratio*float64(plot.Dx())))
+	}
+
+	toY := func(v float64) int {
+		ratio := (v - minV) / (maxV - minV)
+		return plot.Max.Y - int(math.Round(ratio*float64(plot.Dy())))
+	}
+
+	for i := 0; i <= yTickCount; i++ {
+		v := minV + (maxV-minV)*float64(i)/yTickCount
+		y := toY(v)
+		drawHLine(img, plot.Min.X, plot.Max.X, y, gridColor)
+
+		label := fmt.Sprintf(chart.ValueFormat, v)
+		drawText(img, plot.Min.X-measureText(label, 1)-8, y-glyphHeight/2, label, mutedColor, 1)
+	}
+
+	loc := chart.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	for i := 0; i <= xTickCount; i++ {
+		t := minT.Add(time.Duration(float64(maxT.Sub(minT)) * float64(i) / xTickCount))
+		x := toX(t)
+		drawVLine(img, x, plot.Min.Y, plot.Max.Y, gridColor)
+
+		label := t.In(loc).Format("2006-01-02")
+		labelX := min(x-measureText(label, 1)/2, chartWidth-measureText(label, 1)-4)
+		drawText(img, labelX, plot.Max.Y+10, label, mutedColor, 1)
+	}
+
+	drawHLine(img, plot.Min.X, plot.Max.X, plot.Max.Y, axisColor)
+	drawVLine(img, plot.Min.X, plot.Min.Y, plot.Max.Y, axisColor)
+
+	for _, s := range chart.Series {
+		for i, p := range s.Points {
+			x, y := toX(p.Time), toY(p.Value)
+			if i > 0 {
+				prev := s.Points[i-1]
+				drawLine(img, toX(prev.Time), toY(prev.Value), x, y, 2, s.Color)
+			}
+			if len(s.Points) <= 100 {
+				fillRect(img, image.Rect(x-3, y-3, x+3, y+3), s.Color)
+			}
+		}
+	}
+
+	legendX := chartWidth - marginRight
+	for i := len(chart.Series) - 1; i >= 0; i-- {
+		s := chart.Series[i]
+		legendX -= measureText(s.Name, 1)
+		drawText(img, legendX, 30, s.Name, textColor, 1)
+		legendX -= 18
+		fillRect(img, image.Rect(legendX, 32, legendX+12, 40), s.Color)
+		legendX -= 20
+	}
+
+	return encodePng(img)
+}
+
+func getBounds(series []Series) (time.Time, time.Time, float64, float64, bool) {
+	var minT, maxT time.Time
+	minV, maxV := math.Inf(1), math.Inf(-1)
+	found := false
+
+	for _, s := range series {
+		for _, p := range s.Points {
+			if !found || p.Time.Before(minT) {
+				minT = p.Time
+			}
+			if !found || p.Time.After(maxT) {
+				maxT = p.Time
+			}
+			minV = math.Min(minV, p.Value)
+			maxV = math.Max(maxV, p.Value)
+			found = true
+		}
+	}
+
+	if !found {
+		return minT, maxT, minV, maxV, false
+	}
+
+	if !maxT.After(minT) {
+		minT = minT.Add(-12 * time.Hour)
+		maxT = maxT.Add(12 * time.Hour)
+	}
+
+	pad := (maxV - minV) * 0.05
+	if pad == 0 {
+		pad = math.Max(math.Abs(maxV)*0.01, 0.01)
+	}
+
+	return minT, maxT, minV - pad, maxV + pad, true
+}
+
+func encodePng(img image.Image) ([]byte, error) {
+	buf := bytes.Buffer{}
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode png: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
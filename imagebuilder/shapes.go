@@ -0,0 +1,61 @@
+package imagebuilder
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+func fillRect(dst draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+func drawHLine(dst draw.Image, x0 int, x1 int, y int, c color.Color) {
+	fillRect(dst, image.Rect(x0, y, x1+1, y+1), c)
+}
+
+func drawVLine(dst draw.Image, x int, y0 int, y1 int, c color.Color) {
+	fillRect(dst, image.Rect(x, y0, x+1, y1+1), c)
+}
+
+// Draws a line using Bresenham's algorithm, stamping a square of the given thickness on every point.
+func drawLine(dst draw.Image, x0 int, y0 int, x1 int, y1 int, thickness int, c color.Color) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx := 1
+	if x0 > x1 {
+		sx = -1
+	}
+	sy := 1
+	if y0 > y1 {
+		sy = -1
+	}
+
+	half := thickness / 2
+	err := dx + dy
+
+	for {
+		fillRect(dst, image.Rect(x0-half, y0-half, x0-half+thickness, y0-half+thickness), c)
+
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -24,6 +24,14 @@ func main() {
 	store.Bot.SetContext(ctx)
 	defer stop()
 
+	if len(os.Args) > 1 {
+		err := runMaintenance(ctx, os.Args[1])
+		if err != nil {
+			logger.Fatal(ctx, err.Error())
+		}
+		return
+	}
+
 	logger.Info(ctx, "starting up...")
 
 	token := os.Getenv("KAGURA_TOKEN")
@@ -43,6 +51,8 @@ func main() {
 	}
 	logger.Info(ctx, "database ready")
 
+	go db.RunTrashPurger(ctx)
+
 	defer func() {
 		logger.Info(ctx, "closing database")
 		err := db.Close()
@@ -70,11 +80,16 @@ func main() {
 	}
 	logger.Info(ctx, "completed inserting chart cc to db")
 
+	registry, err := commands.NewRegistry(commands.GetCommands(store, db, datasvcs))
+	if err != nil {
+		logger.Fatal(ctx, "failed to build command registry with error "+err.Error())
+	}
+
 	logger.Info(ctx, "registering slash commands")
-	commands.RegisterCommands(ctx, s);
+	commands.RegisterCommands(ctx, s, registry);
 	logger.Info(ctx, "completed registering slash commands")
 
-	hfactory := handler.NewFactory(store, db, datasvcs)
+	hfactory := handler.NewFactory(store, db, datasvcs, registry)
 	s.AddHandler(hfactory.NewOnMessageCreateHandler().Handle)
 	s.AddHandler(hfactory.NewOnInteractionCreateHandler().Handle)
 
@@ -94,3 +109,31 @@ func main() {
 
 	logger.Info(ctx, "received stopping signal, bot exiting")
 }
+
+// Runs a maintenance task against the database and exits, without connecting to Discord.
+func runMaintenance(ctx context.Context, task string) error {
+	if task != "enforce-retention" {
+		return fmt.Errorf("unknown maintenance task %s, expecting enforce-retention", task)
+	}
+
+	db, err := database.NewService(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err := db.Close()
+		if err != nil {
+			logger.Error(ctx, err.Error())
+		}
+	}()
+
+	logger.Info(ctx, fmt.Sprintf("enforcing score retention policy %s", db.RetentionPolicy()))
+	count, err := db.EnforceRetention(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info(ctx, fmt.Sprintf("deleted %v scores outside the retention policy", count))
+
+	return nil
+}
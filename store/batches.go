@@ -0,0 +1,63 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// pending batches are discarded if they are not confirmed within this duration.
+const batchExpiry = 15 * time.Minute
+
+type PendingScore struct {
+	ChartId int
+	Score   int
+	// the time the score was played, or the zero time if it should be saved as played when the batch is confirmed.
+	PlayedAt time.Time
+}
+
+type PendingBatch struct {
+	UserId    int64
+	Scores    []PendingScore
+	CreatedAt time.Time
+}
+
+type batches struct {
+	mu      sync.Mutex
+	pending map[string]PendingBatch
+}
+
+func (b *batches) Add(id string, batch PendingBatch) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pending == nil {
+		b.pending = make(map[string]PendingBatch)
+	}
+
+	for k, v := range b.pending {
+		if time.Since(v.CreatedAt) > batchExpiry {
+			delete(b.pending, k)
+		}
+	}
+
+	b.pending[id] = batch
+}
+
+// Removes the batch and returns it, or false if it does not exist or has expired.
+func (b *batches) Take(id string) (PendingBatch, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batch, ok := b.pending[id]
+	if !ok {
+		return PendingBatch{}, false
+	}
+
+	delete(b.pending, id)
+
+	if time.Since(batch.CreatedAt) > batchExpiry {
+		return PendingBatch{}, false
+	}
+
+	return batch, true
+}
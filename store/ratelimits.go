@@ -0,0 +1,49 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+// interactions of a user beyond this count within the window are rejected.
+const (
+	rateLimitCount  = 10
+	rateLimitWindow = 10 * time.Second
+)
+
+type rateLimits struct {
+	mu   sync.Mutex
+	hits map[int64][]time.Time
+}
+
+// Records an interaction of the user, or returns false without recording it if the user has reached the rate limit.
+func (r *rateLimits) Allow(userId int64, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hits == nil {
+		r.hits = make(map[int64][]time.Time)
+	}
+
+	for k, v := range r.hits {
+		recent := v[:0]
+		for _, t := range v {
+			if now.Sub(t) < rateLimitWindow {
+				recent = append(recent, t)
+			}
+		}
+
+		if len(recent) == 0 {
+			delete(r.hits, k)
+		} else {
+			r.hits[k] = recent
+		}
+	}
+
+	if len(r.hits[userId]) >= rateLimitCount {
+		return false
+	}
+
+	r.hits[userId] = append(r.hits[userId], now)
+	return true
+}
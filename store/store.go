@@ -1,7 +1,9 @@
 package store
 
 type Store struct {
-	Bot bot
+	Bot        bot
+	Batches    batches
+	RateLimits rateLimits
 }
 
 var store Store = Store{}